@@ -15,6 +15,7 @@ type Client interface {
 	AttachLoadBalancerTargetGroups(group string, targetGroupARNs []*string) error
 	DetachLoadBalancerTargetGroups(group string, targetGroupARNs []*string) error
 	DescribeLoadBalancerTargetGroups(group string) ([]*autoscaling.LoadBalancerTargetGroupState, error)
+	UpdateAutoScalingGroup(group string, min, max, desired int64) error
 }
 
 type DefaultClient struct {
@@ -139,3 +140,20 @@ func (c DefaultClient) DescribeLoadBalancerTargetGroups(group string) ([]*autosc
 
 	return result.LoadBalancerTargetGroups, err
 }
+
+func (c DefaultClient) UpdateAutoScalingGroup(group string, min, max, desired int64) error {
+
+	params := autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(group),
+		MinSize:              aws.Int64(min),
+		MaxSize:              aws.Int64(max),
+		DesiredCapacity:      aws.Int64(desired),
+	}
+
+	_, err := c.service.UpdateAutoScalingGroup(&params)
+	if util.IsRateExceeded(err) {
+		return c.UpdateAutoScalingGroup(group, min, max, desired)
+	}
+
+	return err
+}
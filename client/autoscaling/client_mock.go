@@ -101,3 +101,13 @@ func (_m *MockClient) DescribeLoadBalancerTargetGroups(group string) ([]*autosca
 func (_mr *_MockClientRecorder) DescribeLoadBalancerTargetGroups(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DescribeLoadBalancerTargetGroups", arg0)
 }
+
+func (_m *MockClient) UpdateAutoScalingGroup(group string, min int64, max int64, desired int64) error {
+	ret := _m.ctrl.Call(_m, "UpdateAutoScalingGroup", group, min, max, desired)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockClientRecorder) UpdateAutoScalingGroup(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateAutoScalingGroup", arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,100 @@
+package cloudwatchlogs
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+
+	"github.com/openfresh/ecs-formation/client/util"
+)
+
+type Client interface {
+	DescribeLogGroup(region string, name string) (*cloudwatchlogs.LogGroup, error)
+	CreateLogGroup(region string, name string, retentionInDays *int64) error
+}
+
+type DefaultClient struct {
+	session *session.Session
+	region  string
+	service *cloudwatchlogs.CloudWatchLogs
+}
+
+// serviceOf returns client of region, since awslogs-region of containers can be different from the default region.
+func (c DefaultClient) serviceOf(region string) *cloudwatchlogs.CloudWatchLogs {
+
+	if region == "" || region == c.region {
+		return c.service
+	}
+
+	return cloudwatchlogs.New(c.session, aws.NewConfig().WithRegion(region))
+}
+
+// DescribeLogGroup returns the log group of name, or nil if it does not exist.
+func (c DefaultClient) DescribeLogGroup(region string, name string) (*cloudwatchlogs.LogGroup, error) {
+
+	var nextToken *string
+	for {
+		params := cloudwatchlogs.DescribeLogGroupsInput{
+			LogGroupNamePrefix: aws.String(name),
+			NextToken:          nextToken,
+		}
+
+		result, err := c.serviceOf(region).DescribeLogGroups(&params)
+		if util.IsRateExceeded(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range result.LogGroups {
+			if *group.LogGroupName == name {
+				return group, nil
+			}
+		}
+
+		if result.NextToken == nil {
+			return nil, nil
+		}
+		nextToken = result.NextToken
+	}
+}
+
+// CreateLogGroup creates the log group, and sets retention if retentionInDays is not nil.
+// The log group which already exists is regarded as created.
+func (c DefaultClient) CreateLogGroup(region string, name string, retentionInDays *int64) error {
+
+	service := c.serviceOf(region)
+
+	_, err := service.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: aws.String(name),
+	})
+	if util.IsRateExceeded(err) {
+		return c.CreateLogGroup(region, name, retentionInDays)
+	}
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == cloudwatchlogs.ErrCodeResourceAlreadyExistsException {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if retentionInDays == nil {
+		return nil
+	}
+
+	for {
+		_, err := service.PutRetentionPolicy(&cloudwatchlogs.PutRetentionPolicyInput{
+			LogGroupName:    aws.String(name),
+			RetentionInDays: retentionInDays,
+		})
+		if util.IsRateExceeded(err) {
+			continue
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,70 @@
+package ecr
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecr"
+
+	"github.com/openfresh/ecs-formation/client/util"
+)
+
+type Client interface {
+	DescribeImage(region string, registryID string, repository string, reference string) (*ecr.ImageDetail, error)
+}
+
+type DefaultClient struct {
+	session *session.Session
+	region  string
+	service *ecr.ECR
+}
+
+// serviceOf returns client of region, since images can be pulled from registries of other regions.
+func (c DefaultClient) serviceOf(region string) *ecr.ECR {
+
+	if region == "" || region == c.region {
+		return c.service
+	}
+
+	return ecr.New(c.session, aws.NewConfig().WithRegion(region))
+}
+
+// DescribeImage returns the image of reference, which is tag or digest, in the repository.
+// It returns nil if the image or the repository does not exist.
+func (c DefaultClient) DescribeImage(region string, registryID string, repository string, reference string) (*ecr.ImageDetail, error) {
+
+	id := &ecr.ImageIdentifier{ImageTag: aws.String(reference)}
+	if strings.Contains(reference, ":") {
+		id = &ecr.ImageIdentifier{ImageDigest: aws.String(reference)}
+	}
+
+	params := ecr.DescribeImagesInput{
+		RegistryId:     aws.String(registryID),
+		RepositoryName: aws.String(repository),
+		ImageIds:       []*ecr.ImageIdentifier{id},
+	}
+
+	result, err := c.serviceOf(region).DescribeImages(&params)
+	if util.IsRateExceeded(err) {
+		return c.DescribeImage(region, registryID, repository, reference)
+	}
+
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case ecr.ErrCodeImageNotFoundException, ecr.ErrCodeRepositoryNotFoundException:
+			return nil, nil
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.ImageDetails) == 0 {
+		return nil, nil
+	}
+
+	return result.ImageDetails[0], nil
+}
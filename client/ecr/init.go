@@ -0,0 +1,24 @@
+package ecr
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecr"
+)
+
+type Config struct {
+	IsMock bool
+	Region string
+}
+
+func NewClient(ses *session.Session, conf *Config) Client {
+
+	if conf.IsMock {
+		return &MockClient{}
+	}
+
+	return &DefaultClient{
+		session: ses,
+		region:  conf.Region,
+		service: ecr.New(ses),
+	}
+}
@@ -1,6 +1,8 @@
 package ecs
 
 import (
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/openfresh/ecs-formation/client/util"
@@ -12,15 +14,19 @@ type Client interface {
 	DeleteCluster(cluster string) (*ecs.Cluster, error)
 	DescribeClusters(clusters []*string) (*ecs.DescribeClustersOutput, error)
 	ListClusters(maxResult int) (*ecs.ListClustersOutput, error)
+	ListAllClusters() ([]*string, error)
 	ListContainerInstances(cluster string) (*ecs.ListContainerInstancesOutput, error)
+	ListAllContainerInstances(cluster string, filter string) ([]*string, error)
 	CreateService(params *ecs.CreateServiceInput) (*ecs.Service, error)
 	UpdateService(params *ecs.UpdateServiceInput) (*ecs.Service, error)
 	DescribeService(cluster string, services []*string) (*ecs.DescribeServicesOutput, error)
 	DeleteService(cluster string, service string) (*ecs.Service, error)
 	ListServices(cluster string) (*ecs.ListServicesOutput, error)
+	ListAllServices(cluster string) ([]*string, error)
 	DescribeTaskDefinition(td string) (*ecs.TaskDefinition, error)
 	RegisterTaskDefinition(taskName string, containers []*ecs.ContainerDefinition, volumes []*ecs.Volume) (*ecs.TaskDefinition, error)
 	DeregisterTaskDefinition(taskName string) (*ecs.TaskDefinition, error)
+	ListTaskDefinitions(family string) ([]*string, error)
 	ListTasks(cluster string, service string) (*ecs.ListTasksOutput, error)
 	DescribeTasks(cluster string, tasks []*string) (*ecs.DescribeTasksOutput, error)
 	StopTask(cluster string, task string) (*ecs.Task, error)
@@ -85,6 +91,33 @@ func (c DefaultClient) ListClusters(maxResult int) (*ecs.ListClustersOutput, err
 	return result, err
 }
 
+// ListAllClusters returns ARNs of all clusters across pages.
+func (c DefaultClient) ListAllClusters() ([]*string, error) {
+
+	arns := []*string{}
+	var nextToken *string
+	for {
+		params := ecs.ListClustersInput{
+			NextToken: nextToken,
+		}
+
+		result, err := c.service.ListClusters(&params)
+		if util.IsRateExceeded(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		arns = append(arns, result.ClusterArns...)
+		if result.NextToken == nil {
+			return arns, nil
+		}
+		nextToken = result.NextToken
+	}
+}
+
 func (c DefaultClient) ListContainerInstances(cluster string) (*ecs.ListContainerInstancesOutput, error) {
 
 	params := ecs.ListContainerInstancesInput{
@@ -99,6 +132,38 @@ func (c DefaultClient) ListContainerInstances(cluster string) (*ecs.ListContaine
 	return result, err
 }
 
+// ListAllContainerInstances returns ARNs of container instances across pages, which match filter
+// of cluster query language like "ec2InstanceId in ['i-...']". All instances are returned if filter is empty.
+func (c DefaultClient) ListAllContainerInstances(cluster string, filter string) ([]*string, error) {
+
+	arns := []*string{}
+	var nextToken *string
+	for {
+		params := ecs.ListContainerInstancesInput{
+			Cluster:   aws.String(cluster),
+			NextToken: nextToken,
+		}
+		if filter != "" {
+			params.Filter = aws.String(filter)
+		}
+
+		result, err := c.service.ListContainerInstances(&params)
+		if util.IsRateExceeded(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		arns = append(arns, result.ContainerInstanceArns...)
+		if result.NextToken == nil {
+			return arns, nil
+		}
+		nextToken = result.NextToken
+	}
+}
+
 func (c DefaultClient) CreateService(params *ecs.CreateServiceInput) (*ecs.Service, error) {
 
 	result, err := c.service.CreateService(params)
@@ -163,6 +228,34 @@ func (c DefaultClient) ListServices(cluster string) (*ecs.ListServicesOutput, er
 	return result, err
 }
 
+// ListAllServices returns ARNs of all services in the cluster across pages.
+func (c DefaultClient) ListAllServices(cluster string) ([]*string, error) {
+
+	arns := []*string{}
+	var nextToken *string
+	for {
+		params := ecs.ListServicesInput{
+			Cluster:   aws.String(cluster),
+			NextToken: nextToken,
+		}
+
+		result, err := c.service.ListServices(&params)
+		if util.IsRateExceeded(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		arns = append(arns, result.ServiceArns...)
+		if result.NextToken == nil {
+			return arns, nil
+		}
+		nextToken = result.NextToken
+	}
+}
+
 func (c DefaultClient) DescribeTaskDefinition(td string) (*ecs.TaskDefinition, error) {
 
 	params := ecs.DescribeTaskDefinitionInput{
@@ -208,6 +301,41 @@ func (c DefaultClient) DeregisterTaskDefinition(taskName string) (*ecs.TaskDefin
 	return result.TaskDefinition, err
 }
 
+// ListTaskDefinitions returns ARNs of active revisions of family, from the newest.
+func (c DefaultClient) ListTaskDefinitions(family string) ([]*string, error) {
+
+	arns := []*string{}
+	var nextToken *string
+	for {
+		params := ecs.ListTaskDefinitionsInput{
+			FamilyPrefix: aws.String(family),
+			Status:       aws.String(ecs.TaskDefinitionStatusActive),
+			Sort:         aws.String(ecs.SortOrderDesc),
+			NextToken:    nextToken,
+		}
+
+		result, err := c.service.ListTaskDefinitions(&params)
+		if util.IsRateExceeded(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// family is a prefix of the filter, so revisions of other families like 'api-worker' for 'api' are excluded.
+		for _, arn := range result.TaskDefinitionArns {
+			if strings.HasSuffix(strings.TrimRight(*arn, "0123456789"), "/"+family+":") {
+				arns = append(arns, arn)
+			}
+		}
+		if result.NextToken == nil {
+			return arns, nil
+		}
+		nextToken = result.NextToken
+	}
+}
+
 func (c DefaultClient) ListTasks(cluster string, service string) (*ecs.ListTasksOutput, error) {
 
 	params := ecs.ListTasksInput{
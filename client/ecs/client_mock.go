@@ -73,6 +73,17 @@ func (_mr *_MockClientRecorder) ListClusters(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListClusters", arg0)
 }
 
+func (_m *MockClient) ListAllClusters() ([]*string, error) {
+	ret := _m.ctrl.Call(_m, "ListAllClusters")
+	ret0, _ := ret[0].([]*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) ListAllClusters() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListAllClusters")
+}
+
 func (_m *MockClient) ListContainerInstances(cluster string) (*ecs.ListContainerInstancesOutput, error) {
 	ret := _m.ctrl.Call(_m, "ListContainerInstances", cluster)
 	ret0, _ := ret[0].(*ecs.ListContainerInstancesOutput)
@@ -84,6 +95,17 @@ func (_mr *_MockClientRecorder) ListContainerInstances(arg0 interface{}) *gomock
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListContainerInstances", arg0)
 }
 
+func (_m *MockClient) ListAllContainerInstances(cluster string, filter string) ([]*string, error) {
+	ret := _m.ctrl.Call(_m, "ListAllContainerInstances", cluster, filter)
+	ret0, _ := ret[0].([]*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) ListAllContainerInstances(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListAllContainerInstances", arg0, arg1)
+}
+
 func (_m *MockClient) CreateService(params *ecs.CreateServiceInput) (*ecs.Service, error) {
 	ret := _m.ctrl.Call(_m, "CreateService", params)
 	ret0, _ := ret[0].(*ecs.Service)
@@ -139,6 +161,17 @@ func (_mr *_MockClientRecorder) ListServices(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListServices", arg0)
 }
 
+func (_m *MockClient) ListAllServices(cluster string) ([]*string, error) {
+	ret := _m.ctrl.Call(_m, "ListAllServices", cluster)
+	ret0, _ := ret[0].([]*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) ListAllServices(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListAllServices", arg0)
+}
+
 func (_m *MockClient) DescribeTaskDefinition(td string) (*ecs.TaskDefinition, error) {
 	ret := _m.ctrl.Call(_m, "DescribeTaskDefinition", td)
 	ret0, _ := ret[0].(*ecs.TaskDefinition)
@@ -172,6 +205,17 @@ func (_mr *_MockClientRecorder) DeregisterTaskDefinition(arg0 interface{}) *gomo
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeregisterTaskDefinition", arg0)
 }
 
+func (_m *MockClient) ListTaskDefinitions(family string) ([]*string, error) {
+	ret := _m.ctrl.Call(_m, "ListTaskDefinitions", family)
+	ret0, _ := ret[0].([]*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) ListTaskDefinitions(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListTaskDefinitions", arg0)
+}
+
 func (_m *MockClient) ListTasks(cluster string, service string) (*ecs.ListTasksOutput, error) {
 	ret := _m.ctrl.Call(_m, "ListTasks", cluster, service)
 	ret0, _ := ret[0].(*ecs.ListTasksOutput)
@@ -16,6 +16,7 @@ type Client interface {
 	CreateTargetGroup(params *elbv2.CreateTargetGroupInput) ([]*elbv2.TargetGroup, error)
 	DeleteTargetGroup(targetGroupArn string) error
 	DescribeTargetGroup(groupNames []string) (map[string]*elbv2.TargetGroup, error)
+	DescribeTargetGroupByARNs(groupARNs []string) (map[string]*elbv2.TargetGroup, error)
 	ModifyTargetGroup(params *elbv2.ModifyTargetGroupInput) ([]*elbv2.TargetGroup, error)
 	DescribeTargetHealth(targetGroupArn string) ([]*elbv2.TargetHealthDescription, error)
 }
@@ -130,6 +131,30 @@ func (c DefaultClient) DescribeTargetGroup(groupNames []string) (map[string]*elb
 	return tgmap, nil
 }
 
+func (c DefaultClient) DescribeTargetGroupByARNs(groupARNs []string) (map[string]*elbv2.TargetGroup, error) {
+
+	params := elbv2.DescribeTargetGroupsInput{
+		TargetGroupArns: aws.StringSlice(groupARNs),
+	}
+
+	tgmap := map[string]*elbv2.TargetGroup{}
+
+	result, err := c.service.DescribeTargetGroups(&params)
+	if util.IsRateExceeded(err) {
+		return c.DescribeTargetGroupByARNs(groupARNs)
+	}
+
+	if err != nil {
+		return tgmap, err
+	}
+
+	for _, tg := range result.TargetGroups {
+		tgmap[*tg.TargetGroupArn] = tg
+	}
+
+	return tgmap, nil
+}
+
 func (c DefaultClient) ModifyTargetGroup(params *elbv2.ModifyTargetGroupInput) ([]*elbv2.TargetGroup, error) {
 
 	result, err := c.service.ModifyTargetGroup(params)
@@ -151,5 +176,9 @@ func (c DefaultClient) DescribeTargetHealth(targetGroupArn string) ([]*elbv2.Tar
 		return c.DescribeTargetHealth(targetGroupArn)
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
 	return result.TargetHealthDescriptions, nil
 }
@@ -115,6 +115,17 @@ func (_mr *_MockClientRecorder) DescribeTargetGroup(arg0 interface{}) *gomock.Ca
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DescribeTargetGroup", arg0)
 }
 
+func (_m *MockClient) DescribeTargetGroupByARNs(groupARNs []string) (map[string]*elbv2.TargetGroup, error) {
+	ret := _m.ctrl.Call(_m, "DescribeTargetGroupByARNs", groupARNs)
+	ret0, _ := ret[0].(map[string]*elbv2.TargetGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockClientRecorder) DescribeTargetGroupByARNs(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DescribeTargetGroupByARNs", arg0)
+}
+
 func (_m *MockClient) ModifyTargetGroup(params *elbv2.ModifyTargetGroupInput) ([]*elbv2.TargetGroup, error) {
 	ret := _m.ctrl.Call(_m, "ModifyTargetGroup", params)
 	ret0, _ := ret[0].([]*elbv2.TargetGroup)
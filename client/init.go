@@ -10,10 +10,14 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/openfresh/ecs-formation/client/applicationautoscaling"
 	"github.com/openfresh/ecs-formation/client/autoscaling"
+	"github.com/openfresh/ecs-formation/client/cloudwatchlogs"
+	"github.com/openfresh/ecs-formation/client/ecr"
 	"github.com/openfresh/ecs-formation/client/ecs"
 	"github.com/openfresh/ecs-formation/client/elb"
 	"github.com/openfresh/ecs-formation/client/elbv2"
 	"github.com/openfresh/ecs-formation/client/s3"
+	"github.com/openfresh/ecs-formation/client/secretsmanager"
+	"github.com/openfresh/ecs-formation/client/ssm"
 )
 
 var (
@@ -27,6 +31,10 @@ type AWSClient struct {
 	ELB                    elb.Client
 	ELBV2                  elbv2.Client
 	ApplicationAutoscaling applicationautoscaling.Client
+	SSM                    ssm.Client
+	SecretsManager         secretsmanager.Client
+	CloudWatchLogs         cloudwatchlogs.Client
+	ECR                    ecr.Client
 }
 
 func Init(region string, isMock bool) {
@@ -74,6 +82,26 @@ func Init(region string, isMock bool) {
 		Region: region,
 	})
 
+	ssmCli := ssm.NewClient(ses, &ssm.Config{
+		IsMock: isMock,
+		Region: region,
+	})
+
+	secretsManagerCli := secretsmanager.NewClient(ses, &secretsmanager.Config{
+		IsMock: isMock,
+		Region: region,
+	})
+
+	cloudWatchLogsCli := cloudwatchlogs.NewClient(ses, &cloudwatchlogs.Config{
+		IsMock: isMock,
+		Region: region,
+	})
+
+	ecrCli := ecr.NewClient(ses, &ecr.Config{
+		IsMock: isMock,
+		Region: region,
+	})
+
 	AWSCli = AWSClient{
 		ECS:         ecsCli,
 		S3:          s3Cli,
@@ -81,5 +109,9 @@ func Init(region string, isMock bool) {
 		ELB:         elbCli,
 		ELBV2:       elbV2Cli,
 		ApplicationAutoscaling: applicationAutoscalingCli,
+		SSM:            ssmCli,
+		SecretsManager: secretsManagerCli,
+		CloudWatchLogs: cloudWatchLogsCli,
+		ECR:            ecrCli,
 	}
 }
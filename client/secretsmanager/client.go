@@ -0,0 +1,30 @@
+package secretsmanager
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+
+	"github.com/openfresh/ecs-formation/client/util"
+)
+
+type Client interface {
+	GetSecretValue(secretID string) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+type DefaultClient struct {
+	service *secretsmanager.SecretsManager
+}
+
+func (c DefaultClient) GetSecretValue(secretID string) (*secretsmanager.GetSecretValueOutput, error) {
+
+	params := secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	}
+
+	result, err := c.service.GetSecretValue(&params)
+	if util.IsRateExceeded(err) {
+		return c.GetSecretValue(secretID)
+	}
+
+	return result, err
+}
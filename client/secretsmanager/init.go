@@ -0,0 +1,22 @@
+package secretsmanager
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+type Config struct {
+	IsMock bool
+	Region string
+}
+
+func NewClient(ses *session.Session, conf *Config) Client {
+
+	if conf.IsMock {
+		return &MockClient{}
+	}
+
+	return &DefaultClient{
+		service: secretsmanager.New(ses),
+	}
+}
@@ -0,0 +1,65 @@
+package ssm
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+
+	"github.com/openfresh/ecs-formation/client/util"
+)
+
+type Client interface {
+	GetParameter(name string, decryption bool) (*ssm.Parameter, error)
+	GetParametersByPath(path string, decryption bool) ([]*ssm.Parameter, error)
+}
+
+type DefaultClient struct {
+	service *ssm.SSM
+}
+
+func (c DefaultClient) GetParameter(name string, decryption bool) (*ssm.Parameter, error) {
+
+	params := ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(decryption),
+	}
+
+	result, err := c.service.GetParameter(&params)
+	if util.IsRateExceeded(err) {
+		return c.GetParameter(name, decryption)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Parameter, nil
+}
+
+func (c DefaultClient) GetParametersByPath(path string, decryption bool) ([]*ssm.Parameter, error) {
+
+	parameters := []*ssm.Parameter{}
+	var nextToken *string
+	for {
+		params := ssm.GetParametersByPathInput{
+			Path:           aws.String(path),
+			Recursive:      aws.Bool(true),
+			WithDecryption: aws.Bool(decryption),
+			NextToken:      nextToken,
+		}
+
+		result, err := c.service.GetParametersByPath(&params)
+		if util.IsRateExceeded(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		parameters = append(parameters, result.Parameters...)
+		if result.NextToken == nil {
+			return parameters, nil
+		}
+		nextToken = result.NextToken
+	}
+}
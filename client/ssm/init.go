@@ -0,0 +1,22 @@
+package ssm
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+type Config struct {
+	IsMock bool
+	Region string
+}
+
+func NewClient(ses *session.Session, conf *Config) Client {
+
+	if conf.IsMock {
+		return &MockClient{}
+	}
+
+	return &DefaultClient{
+		service: ssm.New(ses),
+	}
+}
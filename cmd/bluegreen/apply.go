@@ -1,8 +1,11 @@
 package bluegreen
 
 import (
+	"github.com/fatih/color"
 	"github.com/openfresh/ecs-formation/logger"
 	"github.com/openfresh/ecs-formation/service"
+	"github.com/openfresh/ecs-formation/service/types"
+	"github.com/openfresh/ecs-formation/util"
 	"github.com/spf13/cobra"
 )
 
@@ -11,17 +14,30 @@ var applyCmd = &cobra.Command{
 	Short: "Apply bluegreen deployment",
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		bgsrv, err := service.NewBlueGreenService(projectDir, bluegreenName, parameters)
+		bgsrv, err := service.NewBlueGreenService(projectDir, overlay, bluegreenName, parameters, locked)
 		if err != nil {
 			return err
 		}
 
-		csrv, err := bgsrv.CreateClusterService()
+		printParameters()
+
+		if allGroups {
+			concurrency, err := cmd.Flags().GetInt("concurrency")
+			if err != nil {
+				return err
+			}
+
+			results, err := bgsrv.ApplyAllBlueGreenDeploys(noDeploy, concurrency)
+			printSummary(results)
+			return err
+		}
+
+		csrv, err := bgsrv.CreateClusterService(bluegreenName)
 		if err != nil {
 			return err
 		}
 
-		plans, err := createBlueGreenPlans(bgsrv, csrv)
+		plans, err := createBlueGreenPlans(bgsrv, csrv, bluegreenName)
 		if err != nil {
 			return err
 		}
@@ -40,3 +56,18 @@ var applyCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func printSummary(results []*types.BlueGreenResult) {
+
+	util.Println()
+	util.PrintlnYellow("BlueGreen deployment summary:")
+	for _, result := range results {
+		if result.Error != nil {
+			util.Println(color.RedString("    %s: failed (%s)", result.Name, result.Error.Error()))
+		} else if result.Skipped {
+			util.PrintlnYellow("    %s: skipped", result.Name)
+		} else if result.Switched {
+			util.PrintlnGreen("    %s: switched %s -> %s", result.Name, result.From, result.To)
+		}
+	}
+}
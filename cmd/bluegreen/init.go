@@ -16,14 +16,19 @@ import (
 )
 
 var (
-	projectDir    string
-	bluegreenName string
-	parameters    map[string]string
-	jsonOutput    bool
-	noDeploy      bool
+	projectDir       string
+	overlay          string
+	bluegreenName    string
+	parameters       map[string]string
+	parameterSources map[string]string
+	jsonOutput       bool
+	noDeploy         bool
+	allGroups        bool
+	locked           bool
 )
 
 type BlueGreenPlanJson struct {
+	Name       string
 	Blue       BlueGreenServiceJson
 	Green      BlueGreenServiceJson
 	Active     string
@@ -34,11 +39,23 @@ type BlueGreenPlanJson struct {
 type BlueGreenServiceJson struct {
 	ClusterARN          string
 	AutoScalingGroupARN string
+	DesiredCapacity     int64
 	Instances           []*autoscaling.Instance
-	TaskDefinition      string
-	DesiredCount        int64
-	PendingCount        int64
-	RunningCount        int64
+	TargetGroupARN      string
+	// TaskDefinition and counts are of the first service, which are kept for groups of single service.
+	TaskDefinition string
+	DesiredCount   int64
+	PendingCount   int64
+	RunningCount   int64
+	Services       []BlueGreenServiceStatusJson
+}
+
+type BlueGreenServiceStatusJson struct {
+	ServiceName    string
+	TaskDefinition string
+	DesiredCount   int64
+	PendingCount   int64
+	RunningCount   int64
 }
 
 var BlueGreenCmd = &cobra.Command{
@@ -54,21 +71,40 @@ var BlueGreenCmd = &cobra.Command{
 
 		region := viper.GetString("aws_region")
 		client.Init(region, false)
+		service.RegisterValueResolvers(client.AWSCli)
+		service.RegisterEnvFileSources(client.AWSCli)
 
 		bg, err := cmd.Flags().GetString("group")
 		if err != nil {
 			return err
 		}
-		if bg == "" {
-			return errors.New("-g (--group) is required")
-		}
 		bluegreenName = bg
 
-		paramTokens, err := cmd.Flags().GetStringSlice("parameter")
+		all, err := cmd.Flags().GetBool("all")
 		if err != nil {
 			return err
 		}
-		parameters = util.ParseKeyValues(paramTokens)
+		allGroups = all
+
+		if bluegreenName == "" && !allGroups {
+			return errors.New("should specify '-g group_name' or '--all' option")
+		}
+		if allGroups {
+			bluegreenName = ""
+		}
+
+		params, sources, err := cmdutil.ResolveParameters(cmd, projectDir)
+		if err != nil {
+			return err
+		}
+		parameters = params
+		parameterSources = sources
+
+		env, err := cmd.Flags().GetString("env")
+		if err != nil {
+			return err
+		}
+		overlay = env
 
 		jo, err := cmd.Flags().GetBool("json-output")
 		if err != nil {
@@ -82,6 +118,12 @@ var BlueGreenCmd = &cobra.Command{
 		}
 		noDeploy = nd
 
+		lk, err := cmd.Flags().GetBool("locked")
+		if err != nil {
+			return err
+		}
+		locked = lk
+
 		return nil
 	},
 }
@@ -91,12 +133,21 @@ func init() {
 	BlueGreenCmd.AddCommand(applyCmd)
 
 	BlueGreenCmd.PersistentFlags().StringP("group", "g", "", "BlueGreen group name")
-	BlueGreenCmd.PersistentFlags().StringSliceP("parameter", "p", make([]string, 0), "parameter 'key=value'")
+	cmdutil.AddParameterFlags(BlueGreenCmd)
 	BlueGreenCmd.PersistentFlags().BoolP("no-deploy", "", false, "Only change load balancer")
 	BlueGreenCmd.PersistentFlags().BoolP("json-output", "j", false, "Print json format")
+	BlueGreenCmd.PersistentFlags().BoolP("locked", "", false, "Refuse services whose task_definition is not pinned to revision")
+
+	applyCmd.Flags().IntP("concurrency", "", 4, "Number of groups applied in parallel with '--all'")
 }
 
-func createBlueGreenPlans(bgsrv service.BlueGreenService, csrv service.ClusterService) ([]*types.BlueGreenPlan, error) {
+func printParameters() {
+	if !jsonOutput {
+		cmdutil.PrintParameters(parameters, parameterSources)
+	}
+}
+
+func createBlueGreenPlans(bgsrv service.BlueGreenService, csrv service.ClusterService, name string) ([]*types.BlueGreenPlan, error) {
 	if jsonOutput {
 		util.Output = false
 		defer func() {
@@ -104,7 +155,9 @@ func createBlueGreenPlans(bgsrv service.BlueGreenService, csrv service.ClusterSe
 		}()
 	}
 
-	bgmap := bgsrv.GetBlueGreenMap()
+	bgmap := map[string]*types.BlueGreen{
+		name: bgsrv.GetBlueGreenMap()[name],
+	}
 
 	cplans, err := csrv.CreateServiceUpdatePlans()
 	if err != nil {
@@ -118,63 +171,89 @@ func createBlueGreenPlans(bgsrv service.BlueGreenService, csrv service.ClusterSe
 
 	jsonItems := []BlueGreenPlanJson{}
 	for _, bgplan := range bgplans {
+		util.PrintlnCyan(fmt.Sprintf("    Parameters = %v", bgplan.Parameters))
 		util.PrintlnCyan("    Blue:")
 		util.PrintlnCyan(fmt.Sprintf("        Cluster = %s", bgplan.Blue.NewService.Cluster))
-		util.PrintlnCyan(fmt.Sprintf("        AutoScalingGroupARN = %s", *bgplan.Blue.AutoScalingGroup.AutoScalingGroupARN))
+		if bgplan.Blue.AutoScalingGroup != nil {
+			util.PrintlnCyan(fmt.Sprintf("        AutoScalingGroupARN = %s", *bgplan.Blue.AutoScalingGroup.AutoScalingGroupARN))
+			util.PrintlnCyan(fmt.Sprintf("        DesiredCapacity = %d", *bgplan.Blue.AutoScalingGroup.DesiredCapacity))
+		}
+		if bgplan.Blue.TargetGroupARN != "" {
+			util.PrintlnCyan(fmt.Sprintf("        TargetGroupARN = %s", bgplan.Blue.TargetGroupARN))
+		}
+		if capacity := bgplan.Blue.NewService.Capacity; capacity != nil {
+			util.PrintlnCyan(fmt.Sprintf("        StandbyCapacity = %d", capacity.Standby))
+			util.PrintlnCyan(fmt.Sprintf("        CoolDown = %d", capacity.CoolDown))
+		}
 		util.PrintlnCyan("        Current services as follows:")
-		for _, bcss := range bgplan.Blue.ClusterUpdatePlan.CurrentServices {
-			bcs := bcss.Service
-			util.PrintlnCyan(fmt.Sprintf("            %s:", *bcs.ServiceName))
-			util.PrintlnCyan(fmt.Sprintf("                ServiceARN = %s", *bcs.ServiceArn))
-			util.PrintlnCyan(fmt.Sprintf("                TaskDefinition = %s", *bcs.TaskDefinition))
-			util.PrintlnCyan(fmt.Sprintf("                DesiredCount = %d", *bcs.DesiredCount))
-			util.PrintlnCyan(fmt.Sprintf("                PendingCount = %d", *bcs.PendingCount))
-			util.PrintlnCyan(fmt.Sprintf("                RunningCount = %d", *bcs.RunningCount))
-		}
-
-		var active string
-		if bgplan.IsBlueWithPrimaryElb() {
-			active = "blue"
+		if bgplan.Blue.ClusterUpdatePlan == nil {
+			util.PrintlnCyan("            No instances are registered.")
 		} else {
-			active = "green"
+			for _, bcss := range bgplan.Blue.ClusterUpdatePlan.CurrentServices {
+				bcs := bcss.Service
+				util.PrintlnCyan(fmt.Sprintf("            %s:", *bcs.ServiceName))
+				util.PrintlnCyan(fmt.Sprintf("                ServiceARN = %s", *bcs.ServiceArn))
+				util.PrintlnCyan(fmt.Sprintf("                TaskDefinition = %s", *bcs.TaskDefinition))
+				util.PrintlnCyan(fmt.Sprintf("                DesiredCount = %d", *bcs.DesiredCount))
+				util.PrintlnCyan(fmt.Sprintf("                PendingCount = %d", *bcs.PendingCount))
+				util.PrintlnCyan(fmt.Sprintf("                RunningCount = %d", *bcs.RunningCount))
+			}
+		}
+
+		active, err := bgplan.ActiveColor()
+		if err != nil {
+			return bgplans, err
 		}
 
 		util.PrintlnGreen("    Green:")
 		util.PrintlnGreen(fmt.Sprintf("        Cluster = %s", bgplan.Green.NewService.Cluster))
-		util.PrintlnGreen(fmt.Sprintf("        AutoScalingGroupARN = %s", *bgplan.Green.AutoScalingGroup.AutoScalingGroupARN))
+		if bgplan.Green.AutoScalingGroup != nil {
+			util.PrintlnGreen(fmt.Sprintf("        AutoScalingGroupARN = %s", *bgplan.Green.AutoScalingGroup.AutoScalingGroupARN))
+			util.PrintlnGreen(fmt.Sprintf("        DesiredCapacity = %d", *bgplan.Green.AutoScalingGroup.DesiredCapacity))
+		}
+		if bgplan.Green.TargetGroupARN != "" {
+			util.PrintlnGreen(fmt.Sprintf("        TargetGroupARN = %s", bgplan.Green.TargetGroupARN))
+		}
+		if capacity := bgplan.Green.NewService.Capacity; capacity != nil {
+			util.PrintlnGreen(fmt.Sprintf("        StandbyCapacity = %d", capacity.Standby))
+			util.PrintlnGreen(fmt.Sprintf("        CoolDown = %d", capacity.CoolDown))
+		}
 		util.PrintlnGreen("        Current services as follows:")
-		for _, gcss := range bgplan.Green.ClusterUpdatePlan.CurrentServices {
-			gcs := gcss.Service
-			util.PrintlnGreen(fmt.Sprintf("            %s:", *gcs.ServiceName))
-			util.PrintlnGreen(fmt.Sprintf("                ServiceARN = %s", *gcs.ServiceArn))
-			util.PrintlnGreen(fmt.Sprintf("                TaskDefinition = %s", *gcs.TaskDefinition))
-			util.PrintlnGreen(fmt.Sprintf("                DesiredCount = %d", *gcs.DesiredCount))
-			util.PrintlnGreen(fmt.Sprintf("                PendingCount = %d", *gcs.PendingCount))
-			util.PrintlnGreen(fmt.Sprintf("                RunningCount = %d", *gcs.RunningCount))
+		if bgplan.Green.ClusterUpdatePlan == nil {
+			util.PrintlnGreen("            No instances are registered.")
+		} else {
+			for _, gcss := range bgplan.Green.ClusterUpdatePlan.CurrentServices {
+				gcs := gcss.Service
+				util.PrintlnGreen(fmt.Sprintf("            %s:", *gcs.ServiceName))
+				util.PrintlnGreen(fmt.Sprintf("                ServiceARN = %s", *gcs.ServiceArn))
+				util.PrintlnGreen(fmt.Sprintf("                TaskDefinition = %s", *gcs.TaskDefinition))
+				util.PrintlnGreen(fmt.Sprintf("                DesiredCount = %d", *gcs.DesiredCount))
+				util.PrintlnGreen(fmt.Sprintf("                PendingCount = %d", *gcs.PendingCount))
+				util.PrintlnGreen(fmt.Sprintf("                RunningCount = %d", *gcs.RunningCount))
+			}
+		}
+
+		if len(bgplan.TargetGroups) > 0 {
+			util.PrintlnYellow("    TargetGroups:")
+			for _, tg := range bgplan.TargetGroups {
+				util.PrintlnYellow(fmt.Sprintf("        %s(primary) = %s", tg.PrimaryName, tg.PrimaryARN))
+				util.PrintlnYellow(fmt.Sprintf("        %s(standby) = %s", tg.StandbyName, tg.StandbyARN))
+			}
+		}
+		if len(bgplan.ListenerRules) > 0 {
+			util.PrintlnYellow("    ListenerRules:")
+			for _, rule := range bgplan.ListenerRules {
+				util.PrintlnYellow(fmt.Sprintf("        %s -> %s", *rule.RuleArn, types.ForwardTargetGroupARN(rule)))
+			}
 		}
+		util.PrintlnYellow(fmt.Sprintf("    Active = %s", active))
 
 		util.Println()
 
 		jsonItems = append(jsonItems, BlueGreenPlanJson{
-
-			Blue: BlueGreenServiceJson{
-				ClusterARN:          *bgplan.Blue.CurrentService.ClusterArn,
-				AutoScalingGroupARN: *bgplan.Blue.AutoScalingGroup.AutoScalingGroupARN,
-				Instances:           bgplan.Blue.AutoScalingGroup.Instances,
-				TaskDefinition:      *bgplan.Blue.CurrentService.TaskDefinition,
-				DesiredCount:        *bgplan.Blue.CurrentService.DesiredCount,
-				PendingCount:        *bgplan.Blue.CurrentService.PendingCount,
-				RunningCount:        *bgplan.Blue.CurrentService.RunningCount,
-			},
-			Green: BlueGreenServiceJson{
-				ClusterARN:          *bgplan.Green.CurrentService.ClusterArn,
-				AutoScalingGroupARN: *bgplan.Green.AutoScalingGroup.AutoScalingGroupARN,
-				Instances:           bgplan.Green.AutoScalingGroup.Instances,
-				TaskDefinition:      *bgplan.Green.CurrentService.TaskDefinition,
-				DesiredCount:        *bgplan.Green.CurrentService.DesiredCount,
-				PendingCount:        *bgplan.Green.CurrentService.PendingCount,
-				RunningCount:        *bgplan.Green.CurrentService.RunningCount,
-			},
+			Name:       name,
+			Blue:       toBlueGreenServiceJson(bgplan.Blue),
+			Green:      toBlueGreenServiceJson(bgplan.Green),
 			PrimaryElb: bgplan.PrimaryElb,
 			StandbyElb: bgplan.StandbyElb,
 			Active:     active,
@@ -187,8 +266,41 @@ func createBlueGreenPlans(bgsrv service.BlueGreenService, csrv service.ClusterSe
 		if err != nil {
 			return bgplans, err
 		}
-		fmt.Println(string(bt))
+		fmt.Println(util.MaskSecrets(string(bt)))
 	}
 
 	return bgplans, nil
 }
+
+func toBlueGreenServiceJson(set *types.ServiceSet) BlueGreenServiceJson {
+
+	item := BlueGreenServiceJson{
+		ClusterARN:     *set.CurrentServices[0].ClusterArn,
+		TargetGroupARN: set.TargetGroupARN,
+		Services:       []BlueGreenServiceStatusJson{},
+	}
+
+	if asg := set.AutoScalingGroup; asg != nil {
+		item.AutoScalingGroupARN = *asg.AutoScalingGroupARN
+		item.DesiredCapacity = *asg.DesiredCapacity
+		item.Instances = asg.Instances
+	}
+
+	first := set.CurrentServices[0]
+	item.TaskDefinition = *first.TaskDefinition
+	item.DesiredCount = *first.DesiredCount
+	item.PendingCount = *first.PendingCount
+	item.RunningCount = *first.RunningCount
+
+	for _, cs := range set.CurrentServices {
+		item.Services = append(item.Services, BlueGreenServiceStatusJson{
+			ServiceName:    *cs.ServiceName,
+			TaskDefinition: *cs.TaskDefinition,
+			DesiredCount:   *cs.DesiredCount,
+			PendingCount:   *cs.PendingCount,
+			RunningCount:   *cs.RunningCount,
+		})
+	}
+
+	return item
+}
@@ -9,18 +9,31 @@ var planCmd = &cobra.Command{
 	Use:   "plan",
 	Short: "Show plan to execute bluegreen deployment",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		bgsrv, err := service.NewBlueGreenService(projectDir, bluegreenName, parameters)
+		bgsrv, err := service.NewBlueGreenService(projectDir, overlay, bluegreenName, parameters, locked)
 		if err != nil {
 			return err
 		}
 
-		csrv, err := bgsrv.CreateClusterService()
-		if err != nil {
-			return err
+		printParameters()
+
+		names := []string{bluegreenName}
+		if allGroups {
+			sorted, err := bgsrv.SortBlueGreenGroups()
+			if err != nil {
+				return err
+			}
+			names = sorted
 		}
 
-		if _, err := createBlueGreenPlans(bgsrv, csrv); err != nil {
-			return err
+		for _, name := range names {
+			csrv, err := bgsrv.CreateClusterService(name)
+			if err != nil {
+				return err
+			}
+
+			if _, err := createBlueGreenPlans(bgsrv, csrv, name); err != nil {
+				return err
+			}
 		}
 
 		return nil
@@ -22,6 +22,7 @@ import (
 	"github.com/openfresh/ecs-formation/cmd/bluegreen"
 	"github.com/openfresh/ecs-formation/cmd/service"
 	"github.com/openfresh/ecs-formation/cmd/task"
+	"github.com/openfresh/ecs-formation/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -47,7 +48,7 @@ to quickly create a Cobra application.`,
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, color.RedString(err.Error()))
+		fmt.Fprintln(os.Stderr, color.RedString(util.MaskSecrets(err.Error())))
 		os.Exit(1)
 	}
 }
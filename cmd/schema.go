@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openfresh/ecs-formation/service"
+	"github.com/openfresh/ecs-formation/util"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [task|service|bluegreen]",
+	Short: "Print JSON Schema of task, service or bluegreen files",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		outputDir, err := cmd.Flags().GetString("output-dir")
+		if err != nil {
+			return err
+		}
+
+		if outputDir != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("kind cannot be specified with --output-dir")
+			}
+			return writeSchemas(outputDir)
+		}
+
+		if len(args) != 1 {
+			return fmt.Errorf("kind is required: %s", strings.Join(service.SchemaKinds, ", "))
+		}
+
+		data, err := marshalSchema(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+// writeSchemas writes '<kind>.schema.json' of all kinds into dir.
+func writeSchemas(dir string) error {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, kind := range service.SchemaKinds {
+		data, err := marshalSchema(kind)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, kind+".schema.json")
+		if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		util.PrintlnGreen("Wrote %s", path)
+	}
+
+	return nil
+}
+
+func marshalSchema(kind string) ([]byte, error) {
+
+	schema, err := service.GenerateSchema(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func init() {
+	schemaCmd.Flags().StringP("output-dir", "o", "", "Write schemas of all kinds into the directory")
+	RootCmd.AddCommand(schemaCmd)
+}
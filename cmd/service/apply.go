@@ -9,7 +9,12 @@ var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Update ecs service on target cluster",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		srv, err := service.NewClusterService(projectDir, []string{cluster}, serviceName, parameters)
+		locked, err := cmd.Flags().GetBool("locked")
+		if err != nil {
+			return err
+		}
+
+		srv, err := service.NewClusterService(projectDir, overlay, []string{cluster}, serviceName, parameters, locked)
 		if err != nil {
 			return err
 		}
@@ -22,3 +27,7 @@ var applyCmd = &cobra.Command{
 		return srv.ApplyServicePlans(plans)
 	},
 }
+
+func init() {
+	applyCmd.Flags().BoolP("locked", "", false, "Refuse services whose task_definition is not pinned to revision")
+}
@@ -15,11 +15,13 @@ import (
 )
 
 var (
-	projectDir  string
-	cluster     string
-	serviceName string
-	parameters  map[string]string
-	jsonOutput  bool
+	projectDir       string
+	overlay          string
+	cluster          string
+	serviceName      string
+	parameters       map[string]string
+	parameterSources map[string]string
+	jsonOutput       bool
 )
 
 var ServiceCmd = &cobra.Command{
@@ -35,6 +37,8 @@ var ServiceCmd = &cobra.Command{
 
 		region := viper.GetString("aws_region")
 		client.Init(region, false)
+		service.RegisterValueResolvers(client.AWSCli)
+		service.RegisterEnvFileSources(client.AWSCli)
 
 		cl, err := cmd.Flags().GetString("cluster")
 		if err != nil {
@@ -61,11 +65,18 @@ var ServiceCmd = &cobra.Command{
 			return errors.New("should specify '-s service_name' or '--all' option")
 		}
 
-		paramTokens, err := cmd.Flags().GetStringSlice("parameter")
+		params, sources, err := cmdutil.ResolveParameters(cmd, projectDir)
 		if err != nil {
 			return err
 		}
-		parameters = util.ParseKeyValues(paramTokens)
+		parameters = params
+		parameterSources = sources
+
+		env, err := cmd.Flags().GetString("env")
+		if err != nil {
+			return err
+		}
+		overlay = env
 
 		jo, err := cmd.Flags().GetBool("json-output")
 		if err != nil {
@@ -83,7 +94,7 @@ func init() {
 
 	ServiceCmd.PersistentFlags().StringP("cluster", "c", "", "ECS Cluster")
 	ServiceCmd.PersistentFlags().StringP("service", "s", "", "ECS Service")
-	ServiceCmd.PersistentFlags().StringSliceP("parameter", "p", make([]string, 0), "parameter 'key=value'")
+	cmdutil.AddParameterFlags(ServiceCmd)
 	ServiceCmd.PersistentFlags().BoolP("json-output", "j", false, "Print json format")
 }
 
@@ -96,6 +107,7 @@ func createClusterPlans(srv service.ClusterService) ([]*types.ServiceUpdatePlan,
 		}()
 	}
 
+	cmdutil.PrintParameters(parameters, parameterSources)
 	util.PrintlnYellow("Checking services on clusters...")
 	plans, err := srv.CreateServiceUpdatePlans()
 	if err != nil {
@@ -126,7 +138,7 @@ func createClusterPlans(srv service.ClusterService) ([]*types.ServiceUpdatePlan,
 			util.PrintlnYellow("        PendingCount = %d", *cs.PendingCount)
 			util.PrintlnYellow("        RunningCount = %d", *cs.RunningCount)
 			if cs.RoleArn != nil {
-				util.PrintlnYellow("        Role = %d", *cs.RoleArn)
+				util.PrintlnYellow("        Role = %s", *cs.RoleArn)
 			}
 			if cs.DeploymentConfiguration != nil {
 				util.PrintlnYellow("        MinimumHealthyPercent = %d", *cs.DeploymentConfiguration.MinimumHealthyPercent)
@@ -176,11 +188,21 @@ func createClusterPlans(srv service.ClusterService) ([]*types.ServiceUpdatePlan,
 
 		util.Println()
 		util.PrintlnYellow("Service update plan '%s':", plan.Name)
+		util.PrintlnYellow("    Parameters = %v", plan.Parameters)
 
 		util.PrintlnYellow("    Services:")
 		for _, add := range plan.NewServices {
 			util.PrintlnYellow("        ####[%s]####\n", add.Name)
-			util.PrintlnYellow("        TaskDefinition = %s", add.TaskDefinition)
+			taskDefinition := plan.TaskDefinitionOf(add)
+			if current, ok := plan.CurrentServices[add.Name]; ok {
+				if name := types.TaskDefinitionName(*current.Service.TaskDefinition); name != taskDefinition {
+					taskDefinition = fmt.Sprintf("%s -> %s", name, taskDefinition)
+				}
+			}
+			if _, pinned := add.PinnedRevision(); !pinned {
+				taskDefinition = fmt.Sprintf("%s (resolved from '%s')", taskDefinition, add.TaskDefinition)
+			}
+			util.PrintlnYellow("        TaskDefinition = %s", taskDefinition)
 			util.PrintlnYellow("        DesiredCount = %d", add.DesiredCount)
 			util.PrintlnYellow("        KeepDesiredCount = %t", add.KeepDesiredCount)
 			if add.MinimumHealthyPercent.Valid {
@@ -237,7 +259,7 @@ func createClusterPlans(srv service.ClusterService) ([]*types.ServiceUpdatePlan,
 		if err != nil {
 			return plans, err
 		}
-		fmt.Println(string(bt))
+		fmt.Println(util.MaskSecrets(string(bt)))
 	}
 
 	return plans, nil
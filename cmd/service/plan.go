@@ -10,7 +10,12 @@ var planCmd = &cobra.Command{
 	Short: "Show plan to update ECS service",
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		srv, err := service.NewClusterService(projectDir, []string{cluster}, serviceName, parameters)
+		locked, err := cmd.Flags().GetBool("locked")
+		if err != nil {
+			return err
+		}
+
+		srv, err := service.NewClusterService(projectDir, overlay, []string{cluster}, serviceName, parameters, locked)
 		if err != nil {
 			return err
 		}
@@ -22,3 +27,7 @@ var planCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func init() {
+	planCmd.Flags().BoolP("locked", "", false, "Refuse services whose task_definition is not pinned to revision")
+}
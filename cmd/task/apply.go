@@ -12,23 +12,55 @@ var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Update task definiton",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ts, err := service.NewTaskService(projectDir, taskDefinition, parameters)
+		ts, err := service.NewTaskService(projectDir, overlay, taskDefinition, parameters)
 		if err != nil {
 			return err
 		}
 
-		plans := createTaskPlans(ts)
+		pinDigests, err := cmd.Flags().GetBool("pin-digests")
+		if err != nil {
+			return err
+		}
+
+		skipImageCheck, err := cmd.Flags().GetBool("skip-image-check")
+		if err != nil {
+			return err
+		}
+
+		plans, err := createTaskPlans(ts, skipImageCheck)
+		if err != nil {
+			return err
+		}
+
+		if pinDigests {
+			if err := ts.PinImageDigests(plans); err != nil {
+				logger.Main.Error(color.Red(err.Error()))
+				return err
+			}
+		}
+
 		result, err := ts.ApplyTaskDefinitionPlans(plans)
 		if err != nil {
 			logger.Main.Error(color.Red(err.Error()))
 			return err
 		}
 
+		if pinDigests {
+			if err := ts.WriteLock(plans, result); err != nil {
+				return err
+			}
+		}
+
 		for _, output := range result {
 			logger.Main.Infof("Registered Task Definition '%s'", *output.Family)
-			logger.Main.Info(color.Cyan(util.StringValueWithIndent(output, 1)))
+			logger.Main.Info(color.Cyan(util.MaskSecrets(util.StringValueWithIndent(output, 1))))
 		}
 
 		return nil
 	},
 }
+
+func init() {
+	applyCmd.Flags().BoolP("pin-digests", "", false, "Register images pinned to digests of registries, and write them to lock file")
+	applyCmd.Flags().BoolP("skip-image-check", "", false, "Skip checking images exist in registries")
+}
@@ -0,0 +1,67 @@
+package task
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/openfresh/ecs-formation/service"
+	"github.com/openfresh/ecs-formation/util"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two revisions of task definition field by field",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if taskDefinition == "" {
+			return errors.New("should specify '-t task_definition_name' option")
+		}
+
+		from, err := cmd.Flags().GetInt64("from")
+		if err != nil {
+			return err
+		}
+
+		to, err := cmd.Flags().GetInt64("to")
+		if err != nil {
+			return err
+		}
+
+		if from < 1 || to < 1 {
+			return errors.New("should specify '--from revision' and '--to revision' options")
+		}
+
+		ts, err := service.NewTaskService(projectDir, overlay, taskDefinition, parameters)
+		if err != nil {
+			return err
+		}
+
+		diffs, err := ts.DiffRevisions(taskDefinition, from, to)
+		if err != nil {
+			return err
+		}
+
+		util.PrintlnCyan("Task Definition '%s': %d -> %d", taskDefinition, from, to)
+		if len(diffs) == 0 {
+			util.PrintlnCyan("    No differences")
+		}
+		for _, diff := range diffs {
+			switch {
+			case strings.HasPrefix(diff, "+"):
+				util.PrintlnGreen("    %s", diff)
+			case strings.HasPrefix(diff, "-"):
+				util.PrintlnYellow("    %s", diff)
+			default:
+				util.PrintlnCyan("    %s", diff)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.Flags().Int64P("from", "", 0, "Revision to compare from")
+	diffCmd.Flags().Int64P("to", "", 0, "Revision to compare to")
+}
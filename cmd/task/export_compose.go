@@ -0,0 +1,63 @@
+package task
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fatih/color"
+	"github.com/openfresh/ecs-formation/logger"
+	"github.com/openfresh/ecs-formation/service"
+	"github.com/openfresh/ecs-formation/util"
+	"github.com/spf13/cobra"
+)
+
+var exportComposeCmd = &cobra.Command{
+	Use:   "export-compose",
+	Short: "Convert task definition into docker-compose file to run it locally",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if taskDefinition == "" {
+			return fmt.Errorf("should specify '-t task_definition_name'")
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		ts, err := service.NewTaskService(projectDir, overlay, taskDefinition, parameters)
+		if err != nil {
+			return err
+		}
+
+		task, ok := ts.GetTaskDefinitions()[taskDefinition]
+		if !ok {
+			return fmt.Errorf("Task definition '%s' is not found. ", taskDefinition)
+		}
+
+		compose, warnings, err := service.ExportCompose(task)
+		if err != nil {
+			return err
+		}
+
+		for _, warning := range warnings {
+			logger.Main.Warn(color.YellowString("%s", warning))
+		}
+
+		if output == "" {
+			fmt.Print(compose)
+			return nil
+		}
+
+		if err := ioutil.WriteFile(output, []byte(compose), 0644); err != nil {
+			return err
+		}
+		util.PrintlnGreen("Wrote %s", output)
+
+		return nil
+	},
+}
+
+func init() {
+	exportComposeCmd.Flags().StringP("output", "o", "", "Write docker-compose file to the path instead of stdout")
+}
@@ -0,0 +1,50 @@
+package task
+
+import (
+	"github.com/openfresh/ecs-formation/service"
+	"github.com/openfresh/ecs-formation/util"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show active revisions of task definition with registered dates and images",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		ts, err := service.NewTaskService(projectDir, overlay, taskDefinition, parameters)
+		if err != nil {
+			return err
+		}
+
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
+		}
+
+		for _, family := range targetFamilies(ts) {
+			revisions, err := ts.GetRevisionHistory(family, limit)
+			if err != nil {
+				return err
+			}
+
+			util.PrintlnCyan("Task Definition '%s':", family)
+			for _, revision := range revisions {
+				registeredAt := "-"
+				if revision.RegisteredAt != nil {
+					registeredAt = revision.RegisteredAt.Local().Format("2006-01-02 15:04:05")
+				}
+				util.PrintlnCyan("    %d  %s", revision.Revision, registeredAt)
+				for _, name := range sortedContainerNames(revision.Images) {
+					util.PrintlnCyan("      %s: %s", name, revision.Images[name])
+				}
+			}
+			util.Println()
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	historyCmd.Flags().IntP("limit", "", 10, "Number of revisions to show from the newest, or 0 for all")
+}
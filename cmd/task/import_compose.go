@@ -0,0 +1,70 @@
+package task
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/openfresh/ecs-formation/logger"
+	"github.com/openfresh/ecs-formation/service"
+	"github.com/openfresh/ecs-formation/util"
+	"github.com/spf13/cobra"
+)
+
+var importComposeCmd = &cobra.Command{
+	Use:   "import-compose [docker-compose.yml]",
+	Short: "Convert docker-compose file into task definition",
+	Long: `Convert services of docker-compose file into containers of a task definition.
+It is printed, or written to task/<name>.yml with '-t name'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if len(args) != 1 {
+			return fmt.Errorf("docker-compose file is required")
+		}
+
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		task, warnings, err := service.ImportCompose(content)
+		if err != nil {
+			return fmt.Errorf("%s: %v", args[0], err)
+		}
+
+		for _, warning := range warnings {
+			logger.Main.Warn(color.YellowString("%s", warning))
+		}
+
+		if taskDefinition == "" {
+			fmt.Print(task)
+			return nil
+		}
+
+		path := service.TaskFilePath(projectDir, taskDefinition)
+		if _, err := os.Stat(path); err == nil && !force {
+			return fmt.Errorf("'%s' already exists. Use --force to overwrite it", path)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(task), 0644); err != nil {
+			return err
+		}
+		util.PrintlnGreen("Wrote %s", path)
+
+		return nil
+	},
+}
+
+func init() {
+	importComposeCmd.Flags().BoolP("force", "f", false, "Overwrite existing task file")
+}
@@ -2,6 +2,8 @@ package task
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/openfresh/ecs-formation/client"
 	cmdutil "github.com/openfresh/ecs-formation/cmd/util"
@@ -13,9 +15,11 @@ import (
 )
 
 var (
-	projectDir     string
-	taskDefinition string
-	parameters     map[string]string
+	projectDir       string
+	overlay          string
+	taskDefinition   string
+	parameters       map[string]string
+	parameterSources map[string]string
 )
 
 // taskCmd represents the task command
@@ -32,6 +36,8 @@ var TaskCmd = &cobra.Command{
 
 		region := viper.GetString("aws_region")
 		client.Init(region, false)
+		service.RegisterValueResolvers(client.AWSCli)
+		service.RegisterEnvFileSources(client.AWSCli)
 
 		td, err := cmd.Flags().GetString("task-definition")
 		if err != nil {
@@ -44,15 +50,22 @@ var TaskCmd = &cobra.Command{
 			return err
 		}
 
-		if taskDefinition == "" && all == false {
+		if taskDefinition == "" && all == false && cmd != importComposeCmd {
 			return errors.New("should specify '-t task_definition_name' or '--all' option")
 		}
 
-		paramTokens, err := cmd.Flags().GetStringSlice("parameter")
+		params, sources, err := cmdutil.ResolveParameters(cmd, projectDir)
 		if err != nil {
 			return err
 		}
-		parameters = util.ParseKeyValues(paramTokens)
+		parameters = params
+		parameterSources = sources
+
+		env, err := cmd.Flags().GetString("env")
+		if err != nil {
+			return err
+		}
+		overlay = env
 
 		return nil
 	},
@@ -62,24 +75,74 @@ func init() {
 	TaskCmd.AddCommand(planCmd)
 	TaskCmd.AddCommand(applyCmd)
 	TaskCmd.AddCommand(revisionCmd)
+	TaskCmd.AddCommand(historyCmd)
+	TaskCmd.AddCommand(diffCmd)
+	TaskCmd.AddCommand(pruneCmd)
 	TaskCmd.AddCommand(runCmd)
+	TaskCmd.AddCommand(importComposeCmd)
+	TaskCmd.AddCommand(exportComposeCmd)
 
 	TaskCmd.PersistentFlags().StringP("task-definition", "t", "", "Task Definition")
-	TaskCmd.PersistentFlags().StringSliceP("parameter", "p", make([]string, 0), "parameter 'key=value'")
+	cmdutil.AddParameterFlags(TaskCmd)
 
 }
 
-func createTaskPlans(srv service.TaskService) []*types.TaskUpdatePlan {
+func createTaskPlans(srv service.TaskService, skipImageCheck bool) ([]*types.TaskUpdatePlan, error) {
+
+	cmdutil.PrintParameters(parameters, parameterSources)
 
 	taskDefs := srv.GetTaskDefinitions()
-	plans := srv.CreateTaskUpdatePlans(taskDefs)
+	plans, err := srv.CreateTaskUpdatePlans(taskDefs)
+	if err != nil {
+		return plans, err
+	}
+
+	if !skipImageCheck {
+		srv.CheckImages(plans)
+	}
+	imageErrors := 0
 
 	for _, plan := range plans {
+		util.PrintlnCyan("Task Definition '%s':", plan.Name)
+		util.PrintlnCyan("    parameters: %v", plan.Parameters)
+		if len(plan.Volumes) > 0 {
+			util.PrintlnCyan("    volumes:")
+			names := []string{}
+			for name := range plan.Volumes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				util.PrintlnCyan("      %s: %v", name, plan.Volumes[name])
+			}
+		}
+		for _, group := range plan.NewLogGroups {
+			util.PrintlnCyan("    (+) log group: %v", group)
+		}
+		diffs, err := srv.CompareLock(plan)
+		if err != nil {
+			return plans, err
+		}
+		for _, diff := range diffs {
+			util.PrintlnYellow("    lock: %s", diff)
+		}
+		for _, name := range sortedContainerNames(plan.ImageErrors) {
+			util.PrintlnYellow("    (!) container '%s': %s", name, plan.ImageErrors[name])
+			imageErrors++
+		}
 		for _, add := range plan.NewContainers {
 			util.PrintlnCyan("    (+) %v", add.Name)
 			util.PrintlnCyan("      image: %v", add.Image)
 			util.PrintlnCyan("      ports: %v", add.Ports)
-			util.PrintlnCyan("      environment:\n%v", util.StringValueWithIndent(add.Environment, 4))
+			util.PrintlnCyan("      environment:")
+			keys := []string{}
+			for key := range add.Environment {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				util.PrintlnCyan("        %s: %s (%s)", key, add.Environment[key], add.EnvironmentSources[key])
+			}
 			util.PrintlnCyan("      links: %v", add.Links)
 			util.PrintlnCyan("      volumes: %v", add.Volumes)
 			util.PrintlnCyan("      volumes_from: %v", add.VolumesFrom)
@@ -106,6 +169,9 @@ func createTaskPlans(srv service.TaskService) []*types.TaskUpdatePlan {
 			if len(add.LogOpt) > 0 {
 				util.PrintlnCyan("      log_opt: %v", util.StringValueWithIndent(add.LogOpt, 4))
 			}
+			if len(add.LogSecretOptions) > 0 {
+				util.PrintlnCyan("      log_secret_options: %v", util.StringValueWithIndent(add.LogSecretOptions, 4))
+			}
 			util.PrintlnCyan("      privileged: %v", add.Privileged)
 			util.PrintlnCyan("      read_only: %v", add.ReadonlyRootFilesystem)
 			if len(add.Ulimits) > 0 {
@@ -113,10 +179,83 @@ func createTaskPlans(srv service.TaskService) []*types.TaskUpdatePlan {
 			}
 			util.PrintlnCyan("      user: %v", add.User)
 			util.PrintlnCyan("      working_dir: %v", add.WorkingDirectory)
+			if len(add.CapAdd) > 0 {
+				util.PrintlnCyan("      cap_add: %v", add.CapAdd)
+			}
+			if len(add.CapDrop) > 0 {
+				util.PrintlnCyan("      cap_drop: %v", add.CapDrop)
+			}
+			if add.Init != nil {
+				util.PrintlnCyan("      init: %v", *add.Init)
+			}
+			if add.ShmSize != nil {
+				util.PrintlnCyan("      shm_size: %vMiB", *add.ShmSize)
+			}
+			if len(add.Tmpfs) > 0 {
+				util.PrintlnCyan("      tmpfs: %v", add.Tmpfs)
+			}
+			if len(add.Devices) > 0 {
+				util.PrintlnCyan("      devices: %v", add.Devices)
+			}
+			if len(add.Sysctls) > 0 {
+				util.PrintlnCyan("      sysctls: %v", util.StringValueWithIndent(add.Sysctls, 4))
+			}
+			if add.StopTimeout != nil {
+				util.PrintlnCyan("      stop_timeout: %v", *add.StopTimeout)
+			}
+			if add.StartTimeout != nil {
+				util.PrintlnCyan("      start_timeout: %v", *add.StartTimeout)
+			}
+			if add.Tty {
+				util.PrintlnCyan("      tty: %v", add.Tty)
+			}
+			if add.StdinOpen {
+				util.PrintlnCyan("      stdin_open: %v", add.StdinOpen)
+			}
+			if add.RepositoryCredentials != "" {
+				util.PrintlnCyan("      repository_credentials: %v", add.RepositoryCredentials)
+			}
+			if add.ResourceRequirements != nil {
+				util.PrintlnCyan("      resource_requirements: %v", util.StringValueWithIndent(add.ResourceRequirements, 4))
+			}
+			if add.FireLens != nil {
+				util.PrintlnCyan("      firelens: %v", util.StringValueWithIndent(add.FireLens, 4))
+			}
 		}
 
 		util.Println()
 	}
 
-	return plans
+	if imageErrors > 0 {
+		return plans, fmt.Errorf("%d images cannot be confirmed. Fix them, or skip the check with '--skip-image-check'", imageErrors)
+	}
+
+	return plans, nil
+}
+
+func sortedContainerNames(values map[string]string) []string {
+
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// targetFamilies returns the family of '-t', or all task definitions of project with '--all'.
+func targetFamilies(srv service.TaskService) []string {
+
+	if taskDefinition != "" {
+		return []string{taskDefinition}
+	}
+
+	families := []string{}
+	for name := range srv.GetTaskDefinitions() {
+		families = append(families, name)
+	}
+	sort.Strings(families)
+
+	return families
 }
@@ -11,12 +11,21 @@ var planCmd = &cobra.Command{
 	Short: "Show plan to update task definiton",
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		ts, err := service.NewTaskService(projectDir, taskDefinition, parameters)
+		ts, err := service.NewTaskService(projectDir, overlay, taskDefinition, parameters)
 		if err != nil {
 			return err
 		}
 
-		createTaskPlans(ts)
-		return nil
+		skipImageCheck, err := cmd.Flags().GetBool("skip-image-check")
+		if err != nil {
+			return err
+		}
+
+		_, err = createTaskPlans(ts, skipImageCheck)
+		return err
 	},
 }
+
+func init() {
+	planCmd.Flags().BoolP("skip-image-check", "", false, "Skip checking images exist in registries")
+}
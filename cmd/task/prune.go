@@ -0,0 +1,55 @@
+package task
+
+import (
+	"errors"
+
+	"github.com/openfresh/ecs-formation/logger"
+	"github.com/openfresh/ecs-formation/service"
+	"github.com/spf13/cobra"
+	"github.com/str1ngs/ansi/color"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Deregister old revisions of task definition which services do not use",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		keep, err := cmd.Flags().GetInt("keep")
+		if err != nil {
+			return err
+		}
+		if keep < 1 {
+			return errors.New("should specify '--keep N' option, which is 1 or more")
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+
+		ts, err := service.NewTaskService(projectDir, overlay, taskDefinition, parameters)
+		if err != nil {
+			return err
+		}
+
+		pruned, err := ts.PruneRevisions(targetFamilies(ts), keep, dryRun)
+		for _, revision := range pruned {
+			if dryRun {
+				logger.Main.Infof("Would deregister Task Definition '%v'", revision)
+			} else {
+				logger.Main.Infof("Deregistered Task Definition '%v'", revision)
+			}
+		}
+		if err != nil {
+			logger.Main.Error(color.Red(err.Error()))
+			return err
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	pruneCmd.Flags().IntP("keep", "", 0, "Number of the newest revisions to keep")
+	pruneCmd.Flags().BoolP("dry-run", "", false, "Show revisions to deregister without deregistering them")
+}
@@ -11,7 +11,7 @@ var revisionCmd = &cobra.Command{
 	Short: "Show current revision of task definition",
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		ts, err := service.NewTaskService(projectDir, taskDefinition, parameters)
+		ts, err := service.NewTaskService(projectDir, overlay, taskDefinition, parameters)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/openfresh/ecs-formation/util"
+	"github.com/spf13/cobra"
+)
+
+const parameterFlagSource = "--parameter"
+
+// ResolveParameters merges parameters of '--env', '--parameter-file' and '--parameter' in this order,
+// and returns values and sources of them. Later sources override earlier ones.
+func ResolveParameters(cmd *cobra.Command, projectDir string) (map[string]string, map[string]string, error) {
+
+	values := map[string]string{}
+	sources := map[string]string{}
+	merge := func(params map[string]string, source string) {
+		for key, value := range params {
+			values[key] = value
+			sources[key] = source
+		}
+	}
+
+	env, err := cmd.Flags().GetString("env")
+	if err != nil {
+		return nil, nil, err
+	}
+	if env != "" {
+		path := filepath.Join(projectDir, "params", fmt.Sprintf("%s.yml", env))
+		if _, err := os.Stat(path); err == nil {
+			params, err := util.ReadParameterFile(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			merge(params, filepath.Join("params", fmt.Sprintf("%s.yml", env)))
+		} else if _, err := os.Stat(filepath.Join(projectDir, "overlays", env)); err != nil {
+			return nil, nil, fmt.Errorf("Environment '%s' is not found in 'params' nor 'overlays'. ", env)
+		}
+	}
+
+	files, err := cmd.Flags().GetStringSlice("parameter-file")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, file := range files {
+		params, err := util.ReadParameterFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		merge(params, file)
+	}
+
+	paramTokens, err := cmd.Flags().GetStringSlice("parameter")
+	if err != nil {
+		return nil, nil, err
+	}
+	merge(util.ParseKeyValues(paramTokens), parameterFlagSource)
+
+	return values, sources, nil
+}
+
+// AddParameterFlags adds flags to pass parameters to cmd.
+func AddParameterFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringSliceP("parameter", "p", make([]string, 0), "parameter 'key=value'")
+	cmd.PersistentFlags().StringSliceP("parameter-file", "", make([]string, 0), "parameter file (YAML or dotenv)")
+	cmd.PersistentFlags().StringP("env", "", "", "environment name to load 'params/<env>.yml' and 'overlays/<env>' in project dir")
+}
+
+// PrintParameters prints resolved parameters and sources of them.
+func PrintParameters(values map[string]string, sources map[string]string) {
+
+	if len(values) == 0 {
+		return
+	}
+
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	util.PrintlnYellow("Parameters:")
+	for _, key := range keys {
+		util.PrintlnYellow("    %s = %s (%s)", key, values[key], sources[key])
+	}
+	util.Println()
+}
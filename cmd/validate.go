@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	cmdutil "github.com/openfresh/ecs-formation/cmd/util"
+	"github.com/openfresh/ecs-formation/service"
+	"github.com/openfresh/ecs-formation/service/types"
+	"github.com/openfresh/ecs-formation/util"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate task, service and bluegreen files without AWS",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		projectDir, err := cmdutil.GetProjectDir()
+		if err != nil {
+			return err
+		}
+
+		// external values are not resolved, so that validation never runs commands of '${cmd:...}'.
+		service.RegisterOfflineResolvers()
+
+		params, _, err := cmdutil.ResolveParameters(cmd, projectDir)
+		if err != nil {
+			return err
+		}
+
+		overlay, err := cmd.Flags().GetString("env")
+		if err != nil {
+			return err
+		}
+
+		strict, err := cmd.Flags().GetBool("strict")
+		if err != nil {
+			return err
+		}
+
+		errs, err := service.ValidateProject(projectDir, overlay, params)
+		if err != nil {
+			return err
+		}
+
+		var errorCount, warningCount int
+		for _, e := range errs {
+			if e.Severity == types.SeverityWarning {
+				warningCount++
+				fmt.Println(color.YellowString(util.MaskSecrets(e.Error())))
+			} else {
+				errorCount++
+				fmt.Println(color.RedString(util.MaskSecrets(e.Error())))
+			}
+		}
+
+		if errorCount > 0 || (strict && warningCount > 0) {
+			return fmt.Errorf("%d errors and %d warnings found in project", errorCount, warningCount)
+		}
+
+		if warningCount > 0 {
+			util.PrintlnYellow("%d warnings found in project.", warningCount)
+			return nil
+		}
+
+		util.PrintlnGreen("No errors found in project.")
+		return nil
+	},
+}
+
+func init() {
+	cmdutil.AddParameterFlags(validateCmd)
+	validateCmd.Flags().BoolP("strict", "", false, "Fail on warnings of lint rules")
+	RootCmd.AddCommand(validateCmd)
+}
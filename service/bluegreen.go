@@ -3,16 +3,16 @@ package service
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	awselbv2 "github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/openfresh/ecs-formation/client"
+	"github.com/openfresh/ecs-formation/logger"
 	"github.com/openfresh/ecs-formation/service/types"
 	"github.com/openfresh/ecs-formation/util"
 )
@@ -20,21 +20,27 @@ import (
 type BlueGreenService interface {
 	GetBlueGreenMap() map[string]*types.BlueGreen
 	CreateBlueGreenPlans(bgmap map[string]*types.BlueGreen, cplans []*types.ServiceUpdatePlan) ([]*types.BlueGreenPlan, error)
-	CreateClusterService() (ClusterService, error)
+	CreateClusterService(name string) (ClusterService, error)
 	ApplyBlueGreenDeploys(clusterService ClusterService, plans []*types.BlueGreenPlan, nodeploy bool) error
+	ApplyAllBlueGreenDeploys(nodeploy bool, concurrency int) ([]*types.BlueGreenResult, error)
+	SortBlueGreenGroups() ([]string, error)
 }
 
 type ConcreteBlueGreenService struct {
 	awsCli        client.AWSClient
 	projectDir    string
+	overlay       string
 	blueGreenName string
 	blueGreenMap  map[string]*types.BlueGreen
 	params        map[string]string
+	locked        bool
 }
 
-func NewBlueGreenService(projectDir string, blueGreenName string, params map[string]string) (BlueGreenService, error) {
+// NewBlueGreenService creates BlueGreenService. If locked is true, services whose task_definition
+// is not pinned to revision are refused as ClusterService.
+func NewBlueGreenService(projectDir string, overlay string, blueGreenName string, params map[string]string, locked bool) (BlueGreenService, error) {
 
-	defs, err := searchBlueGreen(projectDir, blueGreenName, params)
+	defs, err := searchBlueGreen(projectDir, overlay, blueGreenName, params)
 	if err != nil {
 		return nil, err
 	}
@@ -42,44 +48,35 @@ func NewBlueGreenService(projectDir string, blueGreenName string, params map[str
 	return &ConcreteBlueGreenService{
 		awsCli:        client.AWSCli,
 		projectDir:    projectDir,
+		overlay:       overlay,
 		blueGreenName: blueGreenName,
 		blueGreenMap:  defs,
 		params:        params,
+		locked:        locked,
 	}, nil
 }
 
-func searchBlueGreen(projectDir string, blueGreenName string, params map[string]string) (map[string]*types.BlueGreen, error) {
-	clusterDir := projectDir + "/bluegreen"
-	bgmap := map[string]*types.BlueGreen{}
+func searchBlueGreen(projectDir string, overlay string, blueGreenName string, params map[string]string) (map[string]*types.BlueGreen, error) {
 
-	filePattern := regexp.MustCompile(`^.+\/(.+)\.yml$`)
+	bgmap := map[string]*types.BlueGreen{}
 
-	filepath.Walk(clusterDir, func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() || !strings.HasSuffix(path, ".yml") {
-			return nil
-		}
+	files, err := searchYamlFiles(projectDir, overlay, "bluegreen", params)
+	if err != nil {
+		return bgmap, err
+	}
 
-		if blueGreenName != "" && !strings.HasSuffix(path, fmt.Sprintf("%s.yml", blueGreenName)) {
-			return nil
+	for _, file := range files {
+		if blueGreenName != "" && file.Name != blueGreenName {
+			continue
 		}
 
-		content, err := ioutil.ReadFile(path)
+		bg, err := createBlueGreen(file.Content)
 		if err != nil {
-			return err
+			return bgmap, err
 		}
-
-		merged := util.MergeYamlWithParameters(content, params)
-		tokens := filePattern.FindStringSubmatch(path)
-		name := tokens[1]
-
-		bg, err := createBlueGreen(merged)
-		if err != nil {
-			return err
-		}
-		bgmap[name] = bg
-
-		return nil
-	})
+		bg.Parameters = file.Parameters
+		bgmap[file.Name] = bg
+	}
 
 	return bgmap, nil
 }
@@ -88,7 +85,7 @@ func createBlueGreen(data string) (*types.BlueGreen, error) {
 
 	bg := types.BlueGreen{}
 	if err := yaml.Unmarshal([]byte(data), &bg); err != nil {
-		return nil, errors.New(fmt.Sprintf("%v\n\n%v", err.Error(), data))
+		return nil, errors.New(fmt.Sprintf("%v\n\n%v", err.Error(), util.MaskSecrets(data)))
 	}
 
 	return &bg, nil
@@ -108,12 +105,29 @@ func (s ConcreteBlueGreenService) CreateBlueGreenPlans(bgmap map[string]*types.B
 				return bgPlans, err
 			}
 
-			if bgplan.Blue.CurrentService == nil {
-				return bgPlans, fmt.Errorf("Service '%s' is not found. ", bg.Blue.Service)
+			if err := checkCurrentServices(bgplan.Blue); err != nil {
+				return bgPlans, err
 			}
 
-			if bgplan.Green.CurrentService == nil {
-				return bgPlans, fmt.Errorf("Service '%s' is not found. ", bg.Green.Service)
+			if err := checkCurrentServices(bgplan.Green); err != nil {
+				return bgPlans, err
+			}
+
+			if bg.IsServiceMode() {
+				if bgplan.Blue.ClusterUpdatePlan == nil {
+					return bgPlans, fmt.Errorf("ECS Cluster '%s' is not found. ", bg.Blue.Cluster)
+				}
+
+				if bgplan.Green.ClusterUpdatePlan == nil {
+					return bgPlans, fmt.Errorf("ECS Cluster '%s' is not found. ", bg.Green.Cluster)
+				}
+
+				if _, err := bgplan.ActiveColor(); err != nil {
+					return bgPlans, err
+				}
+
+				bgPlans = append(bgPlans, bgplan)
+				continue
 			}
 
 			if bgplan.Blue.AutoScalingGroup == nil {
@@ -124,14 +138,18 @@ func (s ConcreteBlueGreenService) CreateBlueGreenPlans(bgmap map[string]*types.B
 				return bgPlans, fmt.Errorf("AutoScaling Group '%s' is not found. ", bg.Green.AutoscalingGroup)
 			}
 
-			if bgplan.Blue.ClusterUpdatePlan == nil {
+			if bgplan.Blue.ClusterUpdatePlan == nil && bg.Blue.Capacity == nil {
 				return bgPlans, fmt.Errorf("ECS Cluster '%s' is not found. ", bg.Blue.Cluster)
 			}
 
-			if bgplan.Green.ClusterUpdatePlan == nil {
+			if bgplan.Green.ClusterUpdatePlan == nil && bg.Green.Capacity == nil {
 				return bgPlans, fmt.Errorf("ECS Cluster '%s' is not found. ", bg.Green.Cluster)
 			}
 
+			if _, err := bgplan.ActiveColor(); err != nil {
+				return bgPlans, err
+			}
+
 			bgPlans = append(bgPlans, bgplan)
 		}
 	}
@@ -149,39 +167,60 @@ func (s ConcreteBlueGreenService) createBlueGreenPlan(bluegreen *types.BlueGreen
 		clusterMap[cp.Name] = cp
 	}
 
+	bluePlan, err := filterServiceUpdatePlan(clusterMap[blue.Cluster], blue.ServiceNames())
+	if err != nil {
+		return nil, err
+	}
+
+	greenPlan, err := filterServiceUpdatePlan(clusterMap[green.Cluster], green.ServiceNames())
+	if err != nil {
+		return nil, err
+	}
+
 	bgPlan := types.BlueGreenPlan{
+		Mode: bluegreen.Mode,
 		Blue: &types.ServiceSet{
-			ClusterUpdatePlan: clusterMap[blue.Cluster],
+			ClusterUpdatePlan: bluePlan,
 		},
 		Green: &types.ServiceSet{
-			ClusterUpdatePlan: clusterMap[green.Cluster],
+			ClusterUpdatePlan: greenPlan,
 		},
 		PrimaryElb: bluegreen.PrimaryElb,
 		StandbyElb: bluegreen.StandbyElb,
 		ChainElb:   bluegreen.ChainElb,
+		Parameters: bluegreen.Parameters,
 		ElbV2:      bluegreen.ElbV2,
 	}
 
 	// describe services
-	bsrv, err := s.awsCli.ECS.DescribeService(blue.Cluster, []*string{aws.String(blue.Service)})
+	bsrv, err := s.describeServices(blue)
 	if err != nil {
 		return nil, err
 	}
 
 	bgPlan.Blue.NewService = &blue
-	if len(bsrv.Services) > 0 {
-		bgPlan.Blue.CurrentService = bsrv.Services[0]
-	}
+	bgPlan.Blue.CurrentServices = bsrv
 
-	gsrv, err := s.awsCli.ECS.DescribeService(green.Cluster, []*string{aws.String(green.Service)})
+	gsrv, err := s.describeServices(green)
 	if err != nil {
 		return nil, err
 	}
 
 	bgPlan.Green.NewService = &green
-	if len(gsrv.Services) > 0 {
-		bgPlan.Green.CurrentService = gsrv.Services[0]
+	bgPlan.Green.CurrentServices = gsrv
+
+	if bluegreen.IsServiceMode() {
+		if err := s.planListenerRules(&bgPlan, bluegreen); err != nil {
+			return nil, err
+		}
+		return &bgPlan, nil
+	}
+
+	tgs, err := s.resolveTargetGroups(bluegreen.ElbV2)
+	if err != nil {
+		return nil, err
 	}
+	bgPlan.TargetGroups = tgs
 
 	// describe autoscaling group
 	asgmap, err := s.awsCli.Autoscaling.DescribeAutoScalingGroups([]string{
@@ -204,23 +243,223 @@ func (s ConcreteBlueGreenService) createBlueGreenPlan(bluegreen *types.BlueGreen
 	return &bgPlan, nil
 }
 
-func (s ConcreteBlueGreenService) CreateClusterService() (ClusterService, error) {
+// resolveTargetGroups resolves target groups to exact ARNs. Both of names and ARNs are accepted.
+func (s ConcreteBlueGreenService) resolveTargetGroups(conf *types.BlueGreenElbV2) ([]*types.TargetGroupPair, error) {
+
+	pairs := []*types.TargetGroupPair{}
+	if conf == nil || len(conf.TargetGroups) == 0 {
+		return pairs, nil
+	}
+
+	ids := []string{}
+	for _, tg := range conf.TargetGroups {
+		ids = append(ids, tg.PrimaryGroup, tg.StandbyGroup)
+	}
+
+	tgmap, err := s.describeTargetGroups(ids)
+	if err != nil {
+		return pairs, err
+	}
+
+	for _, tg := range conf.TargetGroups {
+		primary := tgmap[tg.PrimaryGroup]
+		standby := tgmap[tg.StandbyGroup]
+
+		pairs = append(pairs, &types.TargetGroupPair{
+			PrimaryName: *primary.TargetGroupName,
+			PrimaryARN:  *primary.TargetGroupArn,
+			StandbyName: *standby.TargetGroupName,
+			StandbyARN:  *standby.TargetGroupArn,
+		})
+	}
+
+	return pairs, nil
+}
+
+// planListenerRules resolves target groups of each color and listener rules switched between them.
+func (s ConcreteBlueGreenService) planListenerRules(bgPlan *types.BlueGreenPlan, bluegreen *types.BlueGreen) error {
+
+	if bluegreen.Blue.TargetGroup == "" || bluegreen.Green.TargetGroup == "" {
+		return errors.New("'target_group' of blue and green is required in service mode")
+	}
 
-	bg, _ := s.blueGreenMap[s.blueGreenName]
+	if bluegreen.ElbV2 == nil || len(bluegreen.ElbV2.ListenerRules) == 0 {
+		return errors.New("'elbv2.listener_rules' is required in service mode")
+	}
+
+	tgmap, err := s.describeTargetGroups([]string{
+		bluegreen.Blue.TargetGroup,
+		bluegreen.Green.TargetGroup,
+	})
+	if err != nil {
+		return err
+	}
+	bgPlan.Blue.TargetGroupARN = *tgmap[bluegreen.Blue.TargetGroup].TargetGroupArn
+	bgPlan.Green.TargetGroupARN = *tgmap[bluegreen.Green.TargetGroup].TargetGroupArn
+
+	rules, err := s.awsCli.ELBV2.DescribeRule(&awselbv2.DescribeRulesInput{
+		RuleArns: aws.StringSlice(bluegreen.ElbV2.ListenerRules),
+	})
+	if err != nil {
+		return err
+	}
+	bgPlan.ListenerRules = rules
+
+	return nil
+}
+
+// describeTargetGroups returns target groups keyed by given names or ARNs.
+func (s ConcreteBlueGreenService) describeTargetGroups(ids []string) (map[string]*awselbv2.TargetGroup, error) {
+
+	tgmap := map[string]*awselbv2.TargetGroup{}
+
+	names := []string{}
+	arns := []string{}
+	for _, id := range ids {
+		if isTargetGroupARN(id) {
+			arns = append(arns, id)
+		} else {
+			names = append(names, id)
+		}
+	}
+
+	byName := map[string]*awselbv2.TargetGroup{}
+	if len(names) > 0 {
+		result, err := s.awsCli.ELBV2.DescribeTargetGroup(names)
+		if err != nil {
+			return tgmap, err
+		}
+		byName = result
+	}
+
+	byARN := map[string]*awselbv2.TargetGroup{}
+	if len(arns) > 0 {
+		result, err := s.awsCli.ELBV2.DescribeTargetGroupByARNs(arns)
+		if err != nil {
+			return tgmap, err
+		}
+		byARN = result
+	}
+
+	for _, id := range ids {
+		var tg *awselbv2.TargetGroup
+		if isTargetGroupARN(id) {
+			tg = byARN[id]
+		} else {
+			tg = byName[id]
+		}
+
+		if tg == nil {
+			return tgmap, fmt.Errorf("Target group '%s' is not found. ", id)
+		}
+		tgmap[id] = tg
+	}
+
+	return tgmap, nil
+}
+
+func isTargetGroupARN(id string) bool {
+	return strings.HasPrefix(id, "arn:")
+}
+
+// describeServices returns current services listed at target in the same order.
+// Services which are not found are set to nil.
+func (s ConcreteBlueGreenService) describeServices(target types.BlueGreenTarget) ([]*ecs.Service, error) {
+
+	names := target.ServiceNames()
+	if len(names) == 0 {
+		return nil, fmt.Errorf("'service' or 'services' is required at cluster '%s'", target.Cluster)
+	}
+
+	result, err := s.awsCli.ECS.DescribeService(target.Cluster, aws.StringSlice(names))
+	if err != nil {
+		return nil, err
+	}
+
+	srvmap := make(map[string]*ecs.Service, len(result.Services))
+	for _, srv := range result.Services {
+		srvmap[*srv.ServiceName] = srv
+	}
+
+	services := make([]*ecs.Service, len(names))
+	for i, name := range names {
+		services[i] = srvmap[name]
+	}
+
+	return services, nil
+}
+
+func checkCurrentServices(set *types.ServiceSet) error {
+
+	for i, name := range set.NewService.ServiceNames() {
+		if set.CurrentServices[i] == nil {
+			return fmt.Errorf("Service '%s' is not found. ", name)
+		}
+	}
+
+	return nil
+}
+
+// filterServiceUpdatePlan narrows plan down to services deployed at the color,
+// so that other services defined in the same cluster are not touched.
+func filterServiceUpdatePlan(plan *types.ServiceUpdatePlan, services []string) (*types.ServiceUpdatePlan, error) {
+
+	if plan == nil {
+		return nil, nil
+	}
+
+	filtered := types.ServiceUpdatePlan{
+		Name:            plan.Name,
+		InstanceARNs:    plan.InstanceARNs,
+		CurrentServices: map[string]*types.ServiceStack{},
+		NewServices:     map[string]*types.Service{},
+		Parameters:      plan.Parameters,
+		TaskDefinitions: map[string]string{},
+	}
+
+	for _, name := range services {
+		add, ok := plan.NewServices[name]
+		if !ok {
+			return nil, fmt.Errorf("Service '%s' is not defined in 'service/%s.yml'. ", name, plan.Name)
+		}
+		filtered.NewServices[name] = add
+		if td, ok := plan.TaskDefinitions[name]; ok {
+			filtered.TaskDefinitions[name] = td
+		}
+
+		if current, ok := plan.CurrentServices[name]; ok {
+			filtered.CurrentServices[name] = current
+		}
+	}
+
+	return &filtered, nil
+}
+
+func (s ConcreteBlueGreenService) CreateClusterService(name string) (ClusterService, error) {
+
+	bg, _ := s.blueGreenMap[name]
 	if bg == nil {
-		return nil, fmt.Errorf("load bluegreen data is failed. %s", s.blueGreenName)
+		return nil, fmt.Errorf("load bluegreen data is failed. %s", name)
 	}
 
 	clusters := []string{
 		bg.Blue.Cluster,
 		bg.Green.Cluster,
 	}
-	// TODO service名渡すか？ bluegreenコマンドでもserviceを渡せるようにする必要あり
-	return NewClusterService(s.projectDir, clusters, "", s.params)
+
+	services := append([]string{}, bg.Blue.ServiceNames()...)
+	services = append(services, bg.Green.ServiceNames()...)
+
+	return NewClusterServiceWithServices(s.projectDir, s.overlay, clusters, services, s.params, s.locked)
 }
 
 func (s ConcreteBlueGreenService) ApplyBlueGreenDeploys(clusterService ClusterService, plans []*types.BlueGreenPlan, nodeploy bool) error {
 
+	// all plans are checked before switching any of them, not to leave a partial rollout.
+	if err := s.checkBlueGreenPolicies(plans); err != nil {
+		return err
+	}
+
 	for _, plan := range plans {
 		if err := s.applyBlueGreenDeploy(clusterService, plan, nodeploy); err != nil {
 			return err
@@ -230,8 +469,251 @@ func (s ConcreteBlueGreenService) ApplyBlueGreenDeploys(clusterService ClusterSe
 	return nil
 }
 
+func (s ConcreteBlueGreenService) checkBlueGreenPolicies(plans []*types.BlueGreenPlan) error {
+
+	for _, bgplan := range plans {
+		if err := checkPolicies(s.projectDir, s.overlay, "bluegreen",
+			fmt.Sprintf("%s/%s", bgplan.Blue.NewService.Cluster, bgplan.Green.NewService.Cluster), bgplan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s ConcreteBlueGreenService) applyBlueGreenDeploy(clusterService ClusterService, bgplan *types.BlueGreenPlan, nodeploy bool) error {
 
 	switcher := NewELBSwitcher(s.awsCli, bgplan)
 	return switcher.Apply(clusterService, bgplan, nodeploy)
 }
+
+// SortBlueGreenGroups returns group names ordered by 'depends_on'.
+func (s ConcreteBlueGreenService) SortBlueGreenGroups() ([]string, error) {
+
+	names := make([]string, 0, len(s.blueGreenMap))
+	for name, bg := range s.blueGreenMap {
+		for _, dep := range bg.DependsOn {
+			if _, ok := s.blueGreenMap[dep]; !ok {
+				return nil, fmt.Errorf("BlueGreen group '%s' depends on unknown group '%s'", name, dep)
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := []string{}
+	visited := map[string]bool{}
+	for len(sorted) < len(names) {
+		progress := false
+		for _, name := range names {
+			if visited[name] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range s.blueGreenMap[name].DependsOn {
+				if !visited[dep] {
+					ready = false
+				}
+			}
+
+			if ready {
+				visited[name] = true
+				sorted = append(sorted, name)
+				progress = true
+			}
+		}
+
+		if !progress {
+			cycle := []string{}
+			for _, name := range names {
+				if !visited[name] {
+					cycle = append(cycle, name)
+				}
+			}
+			return nil, fmt.Errorf("BlueGreen groups have circular 'depends_on': %s", strings.Join(cycle, ", "))
+		}
+	}
+
+	return sorted, nil
+}
+
+// ApplyAllBlueGreenDeploys applies all groups. Groups whose dependencies have been switched
+// run in parallel up to concurrency. Plans of all groups are checked by policies before starting any
+// group, and once a group fails, no more groups are started.
+func (s ConcreteBlueGreenService) ApplyAllBlueGreenDeploys(nodeploy bool, concurrency int) ([]*types.BlueGreenResult, error) {
+
+	sorted, err := s.SortBlueGreenGroups()
+	if err != nil {
+		return []*types.BlueGreenResult{}, err
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	pending := map[string]int{}
+	dependents := map[string][]string{}
+	ready := []string{}
+	for _, name := range sorted {
+		deps := s.blueGreenMap[name].DependsOn
+		pending[name] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], name)
+		}
+		if len(deps) == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	// plans of all groups are created and checked by policies before any group is started.
+	groups := map[string]*blueGreenGroupPlan{}
+	for _, name := range sorted {
+		group, err := s.createBlueGreenGroupPlan(name)
+		if err == nil {
+			err = s.checkBlueGreenPolicies(group.plans)
+		}
+		if err != nil {
+			logger.Main.Errorf("BlueGreen group '%s' is failed: %s", name, err.Error())
+			return skippedResults(sorted, &types.BlueGreenResult{Name: name, Error: err}), err
+		}
+		groups[name] = group
+	}
+
+	resultMap := map[string]*types.BlueGreenResult{}
+	done := make(chan *types.BlueGreenResult)
+	running := 0
+	var firstErr error
+
+	for {
+		for firstErr == nil && running < concurrency && len(ready) > 0 {
+			name := ready[0]
+			ready = ready[1:]
+			running++
+
+			logger.Main.Infof("Start BlueGreen group '%s' ...", name)
+			go func(group *blueGreenGroupPlan) {
+				done <- s.applyBlueGreenGroup(group, nodeploy)
+			}(groups[name])
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-done
+		running--
+		resultMap[result.Name] = result
+
+		if result.Error != nil {
+			logger.Main.Errorf("BlueGreen group '%s' is failed: %s", result.Name, result.Error.Error())
+			if firstErr == nil {
+				firstErr = result.Error
+			}
+			continue
+		}
+
+		for _, dependent := range dependents[result.Name] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	results := []*types.BlueGreenResult{}
+	for _, name := range sorted {
+		results = append(results, resultMap[name])
+	}
+
+	return skippedResults(sorted, results...), firstErr
+}
+
+// skippedResults returns results of groups in order, and groups without result are skipped.
+func skippedResults(names []string, results ...*types.BlueGreenResult) []*types.BlueGreenResult {
+
+	resultMap := map[string]*types.BlueGreenResult{}
+	for _, result := range results {
+		if result != nil {
+			resultMap[result.Name] = result
+		}
+	}
+
+	values := []*types.BlueGreenResult{}
+	for _, name := range names {
+		if result, ok := resultMap[name]; ok {
+			values = append(values, result)
+		} else {
+			values = append(values, &types.BlueGreenResult{
+				Name:    name,
+				Skipped: true,
+			})
+		}
+	}
+
+	return values
+}
+
+// blueGreenGroupPlan is plans of a group with cluster service of its clusters.
+type blueGreenGroupPlan struct {
+	name           string
+	clusterService ClusterService
+	plans          []*types.BlueGreenPlan
+}
+
+func (s ConcreteBlueGreenService) createBlueGreenGroupPlan(name string) (*blueGreenGroupPlan, error) {
+
+	csrv, err := s.CreateClusterService(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cplans, err := csrv.CreateServiceUpdatePlans()
+	if err != nil {
+		return nil, err
+	}
+
+	bgmap := map[string]*types.BlueGreen{
+		name: s.blueGreenMap[name],
+	}
+
+	plans, err := s.CreateBlueGreenPlans(bgmap, cplans)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blueGreenGroupPlan{
+		name:           name,
+		clusterService: csrv,
+		plans:          plans,
+	}, nil
+}
+
+func (s ConcreteBlueGreenService) applyBlueGreenGroup(group *blueGreenGroupPlan, nodeploy bool) *types.BlueGreenResult {
+
+	result := &types.BlueGreenResult{
+		Name: group.name,
+	}
+
+	for _, plan := range group.plans {
+		active, err := plan.ActiveColor()
+		if err != nil {
+			result.Error = err
+			return result
+		}
+
+		if active == types.ColorBlue {
+			result.From, result.To = types.ColorBlue, types.ColorGreen
+		} else {
+			result.From, result.To = types.ColorGreen, types.ColorBlue
+		}
+
+		if err := s.applyBlueGreenDeploy(group.clusterService, plan, nodeploy); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+	result.Switched = true
+
+	return result
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/openfresh/ecs-formation/client"
+	"github.com/openfresh/ecs-formation/logger"
+	"github.com/openfresh/ecs-formation/service/types"
+)
+
+const (
+	containerInstancesInterval = 10 * time.Second
+	containerInstancesRetry    = 60
+)
+
+type CapacityController struct {
+	awsCli client.AWSClient
+}
+
+func NewCapacityController(awscli client.AWSClient) *CapacityController {
+	return &CapacityController{
+		awsCli: awscli,
+	}
+}
+
+// ScaleUp resizes autoscaling group of next to the size of current, and waits until
+// all instances are registered with ECS cluster.
+func (c CapacityController) ScaleUp(current *types.ServiceSet, next *types.ServiceSet, label string) error {
+
+	if next.NewService.Capacity == nil {
+		return nil
+	}
+
+	cur := current.AutoScalingGroup
+	nxt := next.AutoScalingGroup
+	group := *nxt.AutoScalingGroupName
+
+	desired := *cur.DesiredCapacity
+	if *nxt.DesiredCapacity < desired {
+		logger.Main.Infof("Scaling %s group '%s': DesiredCapacity %d -> %d ...", label, group, *nxt.DesiredCapacity, desired)
+		if err := c.awsCli.Autoscaling.UpdateAutoScalingGroup(group, *cur.MinSize, *cur.MaxSize, desired); err != nil {
+			return err
+		}
+	} else {
+		logger.Main.Infof("%s group '%s' already has DesiredCapacity = %d", label, group, *nxt.DesiredCapacity)
+		desired = *nxt.DesiredCapacity
+	}
+
+	if err := c.waitContainerInstances(group, next.NewService.Cluster, desired); err != nil {
+		return err
+	}
+	logger.Main.Infof("Scaled %s group '%s' completely.", label, group)
+
+	return nil
+}
+
+// Restore resizes autoscaling group of next back to its size before ScaleUp,
+// when deployment fails before load balancers are switched.
+func (c CapacityController) Restore(next *types.ServiceSet, label string) error {
+
+	if next.NewService.Capacity == nil {
+		return nil
+	}
+
+	nxt := next.AutoScalingGroup
+	group := *nxt.AutoScalingGroupName
+
+	logger.Main.Warnf("Scaling %s group '%s' back: DesiredCapacity -> %d ...", label, group, *nxt.DesiredCapacity)
+	if err := c.awsCli.Autoscaling.UpdateAutoScalingGroup(group, *nxt.MinSize, *nxt.MaxSize, *nxt.DesiredCapacity); err != nil {
+		return err
+	}
+	logger.Main.Warnf("Scaled %s group '%s' back.", label, group)
+
+	return nil
+}
+
+// ScaleDown waits for cool down, and then resizes autoscaling group of current to standby capacity.
+func (c CapacityController) ScaleDown(current *types.ServiceSet, label string) error {
+
+	capacity := current.NewService.Capacity
+	if capacity == nil {
+		return nil
+	}
+
+	cur := current.AutoScalingGroup
+	group := *cur.AutoScalingGroupName
+
+	if capacity.CoolDown > 0 {
+		logger.Main.Infof("Waiting %d seconds to scale down %s group '%s' ...", capacity.CoolDown, label, group)
+		time.Sleep(time.Duration(capacity.CoolDown) * time.Second)
+	}
+
+	logger.Main.Infof("Scaling %s group '%s': DesiredCapacity %d -> %d ...", label, group, *cur.DesiredCapacity, capacity.Standby)
+	if err := c.awsCli.Autoscaling.UpdateAutoScalingGroup(group, capacity.Standby, *cur.MaxSize, capacity.Standby); err != nil {
+		return err
+	}
+	logger.Main.Infof("Scaled down %s group '%s'.", label, group)
+
+	return nil
+}
+
+// waitContainerInstances waits until desired instances of autoscaling group are InService,
+// and registered with ECS cluster. Instances of other groups in the cluster are not counted.
+func (c CapacityController) waitContainerInstances(group string, cluster string, desired int64) error {
+
+	for i := 0; i < containerInstancesRetry; i++ {
+		time.Sleep(containerInstancesInterval)
+
+		asgmap, err := c.awsCli.Autoscaling.DescribeAutoScalingGroups([]string{group})
+		if err != nil {
+			return err
+		}
+
+		asg, ok := asgmap[group]
+		if !ok {
+			return fmt.Errorf("AutoScaling Group '%s' is not found. ", group)
+		}
+
+		instanceIDs := []string{}
+		for _, instance := range asg.Instances {
+			if *instance.LifecycleState == "InService" {
+				instanceIDs = append(instanceIDs, fmt.Sprintf("'%s'", *instance.InstanceId))
+			}
+		}
+		inService := int64(len(instanceIDs))
+
+		var registered int64
+		if inService > 0 {
+			filter := fmt.Sprintf("ec2InstanceId in [%s]", strings.Join(instanceIDs, ","))
+			arns, err := c.awsCli.ECS.ListAllContainerInstances(cluster, filter)
+			if err != nil {
+				return err
+			}
+			registered = int64(len(arns))
+		}
+
+		if inService >= desired && registered >= desired {
+			logger.Main.Infof("%s: %d/%d instances are registered with cluster '%s'", color.GreenString("Ready"), registered, desired, cluster)
+			return nil
+		}
+		logger.Main.Infof("%s: InService %d/%d, registered %d/%d with cluster '%s'", color.YellowString("Waiting"), inService, desired, registered, desired, cluster)
+	}
+
+	return fmt.Errorf("Instances of AutoScaling Group '%s' were not registered with cluster '%s' in %v. ", group, cluster, containerInstancesInterval*containerInstancesRetry)
+}
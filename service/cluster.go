@@ -3,10 +3,7 @@ package service
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,74 +29,102 @@ type ConcreteClusterService struct {
 	ecsCli            ecs.Client
 	appAutoscalingCli applicationautoscaling.Client
 	projectDir        string
+	overlay           string
 	clusters          []string
-	targetService     string
+	targetServices    []string
 	params            map[string]string
+	locked            bool
 }
 
-func NewClusterService(projectDir string, clusters []string, targetService string, params map[string]string) (ClusterService, error) {
+func NewClusterService(projectDir string, overlay string, clusters []string, targetService string, params map[string]string, locked bool) (ClusterService, error) {
+
+	targetServices := []string{}
+	if targetService != "" {
+		targetServices = append(targetServices, targetService)
+	}
+
+	return NewClusterServiceWithServices(projectDir, overlay, clusters, targetServices, params, locked)
+}
+
+// NewClusterServiceWithServices creates ClusterService which only touches targetServices.
+// If targetServices is empty, all services on clusters are targeted. If locked is true,
+// plans are refused for services whose task_definition is not pinned to revision.
+func NewClusterServiceWithServices(projectDir string, overlay string, clusters []string, targetServices []string, params map[string]string, locked bool) (ClusterService, error) {
 
 	service := ConcreteClusterService{
 		ecsCli:            client.AWSCli.ECS,
 		appAutoscalingCli: client.AWSCli.ApplicationAutoscaling,
 		projectDir:        projectDir,
+		overlay:           overlay,
 		clusters:          clusters,
-		targetService:     targetService,
+		targetServices:    targetServices,
 		params:            params,
+		locked:            locked,
 	}
 
 	return &service, nil
 }
 
-func (s ConcreteClusterService) SearchClusters() ([]types.Cluster, error) {
+func (s ConcreteClusterService) isTargetService(name string) bool {
 
-	clusterDir := s.projectDir + "/service"
-	clusters := []types.Cluster{}
+	if len(s.targetServices) == 0 {
+		return true
+	}
 
-	filePattern := regexp.MustCompile(`^.+\/(.+)\.yml$`)
-	filepath.Walk(clusterDir, func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() || !strings.HasSuffix(path, ".yml") {
-			return nil
+	for _, target := range s.targetServices {
+		if target == name {
+			return true
 		}
+	}
 
-		flg := false
-		for _, cluster := range s.clusters {
-			if strings.HasSuffix(path, fmt.Sprintf("%s.yml", cluster)) {
-				flg = true
-			}
-		}
+	return false
+}
 
-		if !flg {
-			return nil
-		}
+func (s ConcreteClusterService) SearchClusters() ([]types.Cluster, error) {
 
-		content, err := ioutil.ReadFile(path)
-		if err != nil {
-			return err
-		}
+	clusters := []types.Cluster{}
 
-		merged := util.MergeYamlWithParameters(content, s.params)
-		tokens := filePattern.FindStringSubmatch(path)
+	files, err := searchYamlFiles(s.projectDir, s.overlay, "service", s.params)
+	if err != nil {
+		return clusters, err
+	}
 
-		clusterName := tokens[1]
+	for _, file := range files {
+		if !s.isTargetCluster(file.Name) {
+			continue
+		}
+
+		if err := resolveExtends(s.projectDir, file, s.params); err != nil {
+			return clusters, err
+		}
 
-		serviceMap, err := types.CreateServiceMap(merged)
+		serviceMap, err := types.CreateServiceMap(file.Content)
 		if err != nil {
-			return err
+			return clusters, err
 		}
 		cluster := types.Cluster{
-			Name:     clusterName,
-			Services: serviceMap,
+			Name:       file.Name,
+			Services:   serviceMap,
+			Parameters: file.Parameters,
 		}
 
 		clusters = append(clusters, cluster)
-
-		return nil
-	})
+	}
 
 	return clusters, nil
 }
 
+func (s ConcreteClusterService) isTargetCluster(name string) bool {
+
+	for _, cluster := range s.clusters {
+		if cluster == name {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s ConcreteClusterService) CreateServiceUpdatePlans() ([]*types.ServiceUpdatePlan, error) {
 
 	plans := []*types.ServiceUpdatePlan{}
@@ -169,7 +194,7 @@ func (s ConcreteClusterService) createServiceUpdatePlan(cluster types.Cluster) (
 		}
 
 		for _, service := range resDescribeService.Services {
-			if s.targetService == "" || (s.targetService != "" && s.targetService == *service.ServiceName) {
+			if s.isTargetService(*service.ServiceName) {
 
 				autoScaling, err := s.appAutoscalingCli.DescribeScalableTarget(cluster.Name, *service.ServiceName)
 				if err != nil {
@@ -186,21 +211,91 @@ func (s ConcreteClusterService) createServiceUpdatePlan(cluster types.Cluster) (
 
 	newServices := map[string]*types.Service{}
 	for name, newService := range cluster.Services {
-		if s.targetService == "" || (s.targetService != "" && s.targetService == newService.Name) {
+		if s.isTargetService(newService.Name) {
 			s := newService
 			newServices[name] = &s
 		}
 	}
 
+	if s.locked {
+		if err := checkPinnedTaskDefinitions(cluster.Name, newServices); err != nil {
+			return nil, err
+		}
+	}
+
+	taskDefinitions, err := s.resolveTaskDefinitions(newServices)
+	if err != nil {
+		return nil, err
+	}
+
 	return &types.ServiceUpdatePlan{
 		Name:            cluster.Name,
 		InstanceARNs:    lciResult.ContainerInstanceArns,
 		CurrentServices: currentStacks,
 		NewServices:     newServices,
+		Parameters:      cluster.Parameters,
+		TaskDefinitions: taskDefinitions,
 	}, nil
 }
 
+// checkPinnedTaskDefinitions refuses services whose task_definition is 'family' or 'family:latest'.
+func checkPinnedTaskDefinitions(cluster string, services map[string]*types.Service) error {
+
+	unpinned := []string{}
+	for name, service := range services {
+		if _, ok := service.PinnedRevision(); !ok {
+			unpinned = append(unpinned, fmt.Sprintf("%s (%s)", name, service.TaskDefinition))
+		}
+	}
+	if len(unpinned) == 0 {
+		return nil
+	}
+	sort.Strings(unpinned)
+
+	return fmt.Errorf("Task definitions of services on '%s' are not pinned to revisions: %s. Pin them like 'family:revision', or run without '--locked'. ", cluster, strings.Join(unpinned, ", "))
+}
+
+// resolveTaskDefinitions resolves task_definition of services into 'family:revision', so that services are
+// updated to revisions of the plan even if task definitions are registered after it.
+func (s ConcreteClusterService) resolveTaskDefinitions(services map[string]*types.Service) (map[string]string, error) {
+
+	resolved := map[string]string{}
+	taskDefinitions := map[string]string{}
+	for name, service := range services {
+		family, revision := types.SplitTaskDefinition(service.TaskDefinition)
+		target := service.TaskDefinition
+		if revision == types.LatestRevision {
+			target = family
+		}
+
+		if td, ok := resolved[target]; ok {
+			taskDefinitions[name] = td
+			continue
+		}
+
+		result, err := s.ecsCli.DescribeTaskDefinition(target)
+		if err != nil {
+			return nil, fmt.Errorf("Task definition '%s' of service '%s' is not found: %v. ", service.TaskDefinition, name, err)
+		}
+		if *result.Status != awsecs.TaskDefinitionStatusActive {
+			return nil, fmt.Errorf("Task definition '%s' of service '%s' is deregistered. ", service.TaskDefinition, name)
+		}
+
+		td := fmt.Sprintf("%s:%d", *result.Family, *result.Revision)
+		resolved[target] = td
+		taskDefinitions[name] = td
+	}
+
+	return taskDefinitions, nil
+}
+
 func (s ConcreteClusterService) ApplyServicePlans(plans []*types.ServiceUpdatePlan) error {
+	for _, plan := range plans {
+		if err := checkPolicies(s.projectDir, s.overlay, "service", plan.Name, plan); err != nil {
+			return err
+		}
+	}
+
 	logger.Main.Info("Start apply serivces...")
 
 	for _, plan := range plans {
@@ -254,7 +349,7 @@ func (s ConcreteClusterService) ApplyServicePlan(plan *types.ServiceUpdatePlan)
 	}
 	// only new registration
 	for _, add := range plan.NewServices {
-		if s.targetService != "" && s.targetService != add.Name {
+		if !s.isTargetService(add.Name) {
 			continue
 		}
 
@@ -267,7 +362,7 @@ func (s ConcreteClusterService) ApplyServicePlan(plan *types.ServiceUpdatePlan)
 				DesiredCount:   aws.Int64(add.DesiredCount),
 				LoadBalancers:  toLoadBalancersNew(add.LoadBalancers),
 				Role:           aws.String(add.Role),
-				TaskDefinition: aws.String(add.TaskDefinition),
+				TaskDefinition: aws.String(plan.TaskDefinitionOf(add)),
 			}
 			if add.MinimumHealthyPercent.Valid && add.MaximumPercent.Valid {
 				p.DeploymentConfiguration = &awsecs.DeploymentConfiguration{
@@ -311,7 +406,7 @@ func (s ConcreteClusterService) ApplyServicePlan(plan *types.ServiceUpdatePlan)
 				Cluster:        aws.String(plan.Name),
 				Service:        aws.String(add.Name),
 				DesiredCount:   aws.Int64(nextDesiredCount),
-				TaskDefinition: aws.String(add.TaskDefinition),
+				TaskDefinition: aws.String(plan.TaskDefinitionOf(add)),
 			}
 			if add.MinimumHealthyPercent.Valid && add.MaximumPercent.Valid {
 				params.DeploymentConfiguration = &awsecs.DeploymentConfiguration{
@@ -0,0 +1,1008 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/openfresh/ecs-formation/service/types"
+	"github.com/openfresh/ecs-formation/util"
+)
+
+var composeMemoryPattern = regexp.MustCompile(`^(?i)([0-9.]+)\s*([bkmg]?)b?$`)
+
+// composeConverter converts services of docker-compose file into containers of a task definition.
+type composeConverter struct {
+	warnings []string
+	// volumes are task-level volumes converted from top-level 'volumes'.
+	volumes map[string]types.TaskVolume
+}
+
+// ImportCompose converts services of docker-compose file (version 2 and 3) into a task file,
+// which has a container for each service. Keys which cannot be translated are returned as warnings.
+func ImportCompose(content []byte) (string, []string, error) {
+
+	doc := yaml.MapSlice{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return "", []string{}, err
+	}
+
+	c := &composeConverter{
+		warnings: []string{},
+		volumes:  map[string]types.TaskVolume{},
+	}
+
+	for _, item := range doc {
+		if fmt.Sprint(item.Key) == "volumes" {
+			c.convertTopLevelVolumes(item.Value)
+		}
+	}
+
+	var services yaml.MapSlice
+	for _, item := range doc {
+		key := fmt.Sprint(item.Key)
+		switch {
+		case key == "version" || key == "volumes" || strings.HasPrefix(key, "x-"):
+		case key == "services":
+			s, ok := item.Value.(yaml.MapSlice)
+			if !ok {
+				return "", []string{}, fmt.Errorf("'services' should be map of services")
+			}
+			services = s
+		case key == "networks" || key == "secrets" || key == "configs":
+			c.warn("top-level '%s' is not translated", key)
+		default:
+			c.warn("top-level '%s' is not supported and ignored", key)
+		}
+	}
+
+	if services == nil {
+		return "", []string{}, fmt.Errorf("'services' is not found. Compose file version 1 is not supported. ")
+	}
+
+	task := yaml.MapSlice{}
+	for _, item := range services {
+		name := fmt.Sprint(item.Key)
+		service, ok := item.Value.(yaml.MapSlice)
+		if !ok {
+			return "", []string{}, fmt.Errorf("service '%s' should be map", name)
+		}
+
+		task = append(task, yaml.MapItem{
+			Key:   name,
+			Value: c.convertService(name, service),
+		})
+	}
+
+	if len(c.volumes) > 0 {
+		task = append(task, yaml.MapItem{Key: "volumes", Value: c.volumes})
+	}
+
+	out, err := yaml.Marshal(task)
+	if err != nil {
+		return "", []string{}, err
+	}
+
+	return string(out), c.warnings, nil
+}
+
+// convertTopLevelVolumes converts named volumes into Docker volumes shared on container instance.
+func (c *composeConverter) convertTopLevelVolumes(value interface{}) {
+
+	volumes, _ := value.(yaml.MapSlice)
+	for _, item := range volumes {
+		name := fmt.Sprint(item.Key)
+		autoprovision := true
+		volume := types.TaskVolume{
+			Driver:        "local",
+			Scope:         ecs.ScopeShared,
+			Autoprovision: &autoprovision,
+		}
+
+		conf, _ := item.Value.(yaml.MapSlice)
+		for _, v := range conf {
+			switch key := fmt.Sprint(v.Key); key {
+			case "driver":
+				volume.Driver = fmt.Sprint(v.Value)
+			case "driver_opts":
+				volume.DriverOpts = composeMap(v.Value)
+			case "labels":
+				volume.Labels = composeMap(v.Value)
+			case "external":
+				if external, _ := v.Value.(bool); external {
+					autoprovision = false
+				}
+			default:
+				c.warn("volume '%s': '%s' is not supported and ignored", name, key)
+			}
+		}
+
+		c.volumes[name] = volume
+	}
+}
+
+func (c *composeConverter) warn(format string, a ...interface{}) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, a...))
+}
+
+func (c *composeConverter) convertService(name string, service yaml.MapSlice) yaml.MapSlice {
+
+	con := map[string]interface{}{
+		"essential": true,
+	}
+
+	for _, item := range service {
+		key := fmt.Sprint(item.Key)
+		value := item.Value
+
+		switch key {
+		case "image", "hostname", "user", "working_dir":
+			con[key] = fmt.Sprint(value)
+		case "command", "entrypoint":
+			target := key
+			if key == "entrypoint" {
+				target = "entry_point"
+			}
+			if list, ok := value.([]interface{}); ok {
+				con[target] = composeStrings(list)
+			} else {
+				con[target] = fmt.Sprint(value)
+			}
+		case "environment":
+			con["environment"] = c.convertEnvironment(name, value)
+		case "env_file", "dns", "dns_search", "security_opt", "links", "cap_add", "cap_drop", "devices":
+			con[key] = composeStrings(value)
+		case "labels", "sysctls":
+			con[key] = composeMap(value)
+		case "shm_size":
+			c.setMemory(con, name, "shm_size", key, value)
+		case "tmpfs":
+			con["tmpfs"] = c.convertTmpfs(name, value)
+		case "stop_grace_period":
+			d, err := time.ParseDuration(fmt.Sprint(value))
+			if err != nil {
+				c.warn("service '%s': %s '%v' is invalid and ignored", name, key, value)
+				continue
+			}
+			con["stop_timeout"] = int64(math.Ceil(d.Seconds()))
+		case "extra_hosts":
+			if m, ok := value.(yaml.MapSlice); ok {
+				hosts := []string{}
+				for _, host := range m {
+					hosts = append(hosts, fmt.Sprintf("%v:%v", host.Key, host.Value))
+				}
+				con["extra_hosts"] = hosts
+			} else {
+				con["extra_hosts"] = composeStrings(value)
+			}
+		case "ports":
+			con["ports"] = c.convertPorts(name, value)
+		case "volumes":
+			con["volumes"] = c.convertVolumes(name, value)
+		case "volumes_from":
+			volumes := []string{}
+			for _, v := range composeStrings(value) {
+				if strings.HasPrefix(v, "container:") {
+					c.warn("service '%s': volumes_from '%s' refers to a container outside of compose file and is ignored", name, v)
+					continue
+				}
+				volumes = append(volumes, strings.TrimPrefix(strings.TrimSuffix(v, ":rw"), "service:"))
+			}
+			con["volumes_from"] = volumes
+		case "mem_limit", "mem_reservation":
+			target := "memory"
+			if key == "mem_reservation" {
+				target = "memory_reservation"
+			}
+			c.setMemory(con, name, target, key, value)
+		case "cpu_shares":
+			c.setInt(con, name, "cpu_units", key, value)
+		case "cpus":
+			c.setCPUs(con, name, key, value)
+		case "deploy":
+			c.convertDeploy(con, name, value)
+		case "logging":
+			m, _ := value.(yaml.MapSlice)
+			for _, l := range m {
+				switch fmt.Sprint(l.Key) {
+				case "driver":
+					con["log_driver"] = fmt.Sprint(l.Value)
+				case "options":
+					con["log_opt"] = composeMap(l.Value)
+				}
+			}
+		case "log_driver":
+			con["log_driver"] = fmt.Sprint(value)
+		case "log_opt":
+			con["log_opt"] = composeMap(value)
+		case "privileged", "read_only", "init", "tty", "stdin_open":
+			if b, ok := value.(bool); ok {
+				con[key] = b
+			} else {
+				c.warn("service '%s': '%s' should be boolean and is ignored", name, key)
+			}
+		case "ulimits":
+			con["ulimits"] = c.convertUlimits(name, value)
+		case "healthcheck":
+			if hc := c.convertHealthCheck(name, value); hc != nil {
+				con["health_check"] = hc
+			}
+		case "network_mode":
+			switch mode := fmt.Sprint(value); mode {
+			case "none":
+				con["disable_networking"] = true
+			case "bridge":
+			default:
+				c.warn("service '%s': network_mode '%s' is not supported and ignored", name, mode)
+			}
+		case "container_name":
+			c.warn("service '%s': container_name is ignored, and container is named '%s'", name, name)
+		case "depends_on":
+			c.warn("service '%s': depends_on is not translated. Use links to order containers", name)
+		case "expose":
+			c.warn("service '%s': expose is ignored, since containers linked on ECS can connect to any ports", name)
+		default:
+			if strings.HasPrefix(key, "x-") {
+				continue
+			}
+			c.warn("service '%s': '%s' is not supported and ignored", name, key)
+		}
+	}
+
+	if _, ok := con["image"]; !ok {
+		c.warn("service '%s': image is not found. Build and push image, and set it to 'image'", name)
+	}
+
+	_, hasMemory := con["memory"]
+	_, hasReservation := con["memory_reservation"]
+	if !hasMemory && !hasReservation {
+		c.warn("service '%s': memory is not limited. Set 'memory' or 'memory_reservation'", name)
+	}
+
+	return orderContainerKeys(con)
+}
+
+func (c *composeConverter) convertEnvironment(name string, value interface{}) map[string]string {
+
+	env := map[string]string{}
+	set := func(key string, v interface{}, ok bool) {
+		if !ok || v == nil {
+			c.warn("service '%s': environment '%s' has no value, so it is written as parameter '${%s}'", name, key, key)
+			env[key] = fmt.Sprintf("${%s}", key)
+			return
+		}
+		env[key] = fmt.Sprint(v)
+	}
+
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for _, item := range v {
+			set(fmt.Sprint(item.Key), item.Value, true)
+		}
+	case []interface{}:
+		for _, item := range v {
+			tokens := strings.SplitN(fmt.Sprint(item), "=", 2)
+			if len(tokens) == 2 {
+				set(tokens[0], tokens[1], true)
+			} else {
+				set(tokens[0], nil, false)
+			}
+		}
+	}
+
+	return env
+}
+
+func (c *composeConverter) convertPorts(name string, value interface{}) []string {
+
+	ports := []string{}
+	list, _ := value.([]interface{})
+	for _, item := range list {
+		var port string
+		if m, ok := item.(yaml.MapSlice); ok {
+			// long syntax
+			values := map[string]string{}
+			for _, p := range m {
+				values[fmt.Sprint(p.Key)] = fmt.Sprint(p.Value)
+			}
+			port = fmt.Sprintf("%s:%s", values["published"], values["target"])
+			if protocol, ok := values["protocol"]; ok {
+				port = fmt.Sprintf("%s/%s", port, protocol)
+			}
+		} else {
+			port = fmt.Sprint(item)
+			if !strings.Contains(port, ":") {
+				// container port only is mapped to dynamic host port in docker-compose.
+				port = ":" + port
+			}
+		}
+
+		if ip := types.ExtractHostIP(port); ip != "" {
+			c.warn("service '%s': host IP '%s' of port '%s' is ignored by ECS", name, ip, port)
+			tokens := strings.Split(port, ":")
+			port = strings.Join(tokens[len(tokens)-2:], ":")
+		}
+
+		if _, err := types.ExpandPortMapping(port); err != nil {
+			c.warn("service '%s': port '%s' is invalid and ignored", name, port)
+			continue
+		}
+		ports = append(ports, port)
+	}
+
+	return ports
+}
+
+func (c *composeConverter) convertVolumes(name string, value interface{}) []string {
+
+	volumes := []string{}
+	list, _ := value.([]interface{})
+	for _, item := range list {
+		var source, target string
+		var readOnly bool
+
+		if m, ok := item.(yaml.MapSlice); ok {
+			// long syntax
+			values := map[string]interface{}{}
+			for _, v := range m {
+				values[fmt.Sprint(v.Key)] = v.Value
+			}
+			if t := fmt.Sprint(values["type"]); t != "bind" && t != "volume" {
+				c.warn("service '%s': volume of type '%s' is not translated", name, t)
+				continue
+			}
+			source = fmt.Sprint(values["source"])
+			target = fmt.Sprint(values["target"])
+			readOnly, _ = values["read_only"].(bool)
+		} else {
+			tokens := strings.Split(fmt.Sprint(item), ":")
+			if len(tokens) == 1 {
+				c.warn("service '%s': anonymous volume '%s' is not translated", name, tokens[0])
+				continue
+			}
+			source, target = tokens[0], tokens[1]
+			if len(tokens) > 2 {
+				for _, mode := range strings.Split(tokens[2], ",") {
+					if mode == "ro" {
+						readOnly = true
+					}
+				}
+			}
+		}
+
+		_, named := c.volumes[source]
+		switch {
+		case named:
+		case strings.HasPrefix(source, "/"):
+		case strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~"):
+			c.warn("service '%s': host path '%s' is relative. Change it to absolute path on container instances", name, source)
+		default:
+			c.warn("service '%s': volume '%s' is not declared in top-level volumes and ignored", name, source)
+			continue
+		}
+
+		volume := fmt.Sprintf("%s:%s", source, target)
+		if readOnly {
+			volume += ":ro"
+		}
+		volumes = append(volumes, volume)
+	}
+
+	return volumes
+}
+
+func (c *composeConverter) convertDeploy(con map[string]interface{}, name string, value interface{}) {
+
+	deploy, _ := value.(yaml.MapSlice)
+	for _, item := range deploy {
+		key := fmt.Sprint(item.Key)
+		if key != "resources" {
+			c.warn("service '%s': deploy.%s is not translated. Configure it in service file", name, key)
+			continue
+		}
+
+		resources, _ := item.Value.(yaml.MapSlice)
+		for _, r := range resources {
+			kind := fmt.Sprint(r.Key)
+			limits, _ := r.Value.(yaml.MapSlice)
+			for _, l := range limits {
+				path := fmt.Sprintf("deploy.resources.%s.%v", kind, l.Key)
+				switch fmt.Sprintf("%s.%v", kind, l.Key) {
+				case "limits.memory":
+					c.setMemory(con, name, "memory", path, l.Value)
+				case "reservations.memory":
+					c.setMemory(con, name, "memory_reservation", path, l.Value)
+				case "reservations.cpus":
+					c.setCPUs(con, name, path, l.Value)
+				case "reservations.devices":
+					c.convertDevices(con, name, path, l.Value)
+				case "limits.cpus":
+					// ECS reserves cpu units, so reservation is preferred to limit.
+					if _, ok := con["cpu_units"]; !ok {
+						c.setCPUs(con, name, path, l.Value)
+					}
+				default:
+					c.warn("service '%s': %s is not translated", name, path)
+				}
+			}
+		}
+	}
+}
+
+// convertTmpfs converts tmpfs of docker-compose into 'containerPath:size=64m', since ECS requires size.
+// convertHealthCheck converts 'healthcheck' into 'health_check' of seconds. It is nil if disabled.
+func (c *composeConverter) convertHealthCheck(name string, value interface{}) yaml.MapSlice {
+
+	m, ok := value.(yaml.MapSlice)
+	if !ok {
+		c.warn("service '%s': healthcheck is invalid and ignored", name)
+		return nil
+	}
+
+	hc := yaml.MapSlice{}
+	for _, item := range m {
+		key := fmt.Sprint(item.Key)
+		switch key {
+		case "test":
+			if list, ok := item.Value.([]interface{}); ok {
+				command := composeStrings(list)
+				if len(command) > 0 && command[0] == "NONE" {
+					return nil
+				}
+				hc = append(hc, yaml.MapItem{Key: "command", Value: command})
+			} else {
+				hc = append(hc, yaml.MapItem{Key: "command", Value: fmt.Sprint(item.Value)})
+			}
+		case "interval", "timeout", "start_period":
+			d, err := time.ParseDuration(fmt.Sprint(item.Value))
+			if err != nil {
+				c.warn("service '%s': healthcheck %s '%v' is invalid and ignored", name, key, item.Value)
+				continue
+			}
+			hc = append(hc, yaml.MapItem{Key: key, Value: int64(math.Ceil(d.Seconds()))})
+		case "retries":
+			if n, ok := item.Value.(int); ok {
+				hc = append(hc, yaml.MapItem{Key: key, Value: n})
+			} else {
+				c.warn("service '%s': healthcheck retries '%v' is invalid and ignored", name, item.Value)
+			}
+		case "disable":
+			if b, ok := item.Value.(bool); ok && b {
+				return nil
+			}
+		default:
+			c.warn("service '%s': healthcheck '%s' is not supported and ignored", name, key)
+		}
+	}
+
+	return hc
+}
+
+func (c *composeConverter) convertTmpfs(name string, value interface{}) []string {
+
+	values := []string{}
+	for _, tmpfs := range composeStrings(value) {
+		tokens := strings.SplitN(tmpfs, ":", 2)
+		options := []string{}
+		hasSize := false
+		if len(tokens) > 1 {
+			for _, option := range strings.Split(tokens[1], ",") {
+				if strings.HasPrefix(option, "size=") {
+					mib, err := parseComposeMemory(strings.TrimPrefix(option, "size="))
+					if err != nil {
+						c.warn("service '%s': size of tmpfs '%s' is invalid and ignored", name, tmpfs)
+						continue
+					}
+					option = fmt.Sprintf("size=%dm", mib)
+					hasSize = true
+				}
+				if option != "" {
+					options = append(options, option)
+				}
+			}
+		}
+		if !hasSize {
+			c.warn("service '%s': tmpfs '%s' has no size, which ECS requires. Set it like '%s:size=64m'", name, tmpfs, tokens[0])
+		}
+
+		if len(options) > 0 {
+			values = append(values, fmt.Sprintf("%s:%s", tokens[0], strings.Join(options, ",")))
+		} else {
+			values = append(values, tokens[0])
+		}
+	}
+
+	return values
+}
+
+// convertDevices converts GPUs of deploy.resources.reservations.devices into resource_requirements.
+func (c *composeConverter) convertDevices(con map[string]interface{}, name string, path string, value interface{}) {
+
+	devices, _ := value.([]interface{})
+	for _, item := range devices {
+		device, _ := item.(yaml.MapSlice)
+		isGPU := false
+		var count interface{} = "all"
+		for _, d := range device {
+			switch fmt.Sprint(d.Key) {
+			case "capabilities":
+				for _, capability := range composeStrings(d.Value) {
+					isGPU = isGPU || capability == "gpu"
+				}
+			case "count":
+				count = d.Value
+			}
+		}
+
+		if !isGPU {
+			c.warn("service '%s': %s except GPU is not translated", name, path)
+			continue
+		}
+
+		n, err := strconv.ParseInt(fmt.Sprint(count), 10, 64)
+		if err != nil || n <= 0 {
+			c.warn("service '%s': GPU count '%v' of %s is not translated. Set 'resource_requirements.gpu'", name, count, path)
+			continue
+		}
+		con["resource_requirements"] = map[string]int64{"gpu": n}
+	}
+}
+
+func (c *composeConverter) convertUlimits(name string, value interface{}) yaml.MapSlice {
+
+	ulimits := yaml.MapSlice{}
+	m, _ := value.(yaml.MapSlice)
+	for _, item := range m {
+		var soft, hard int64
+		if limits, ok := item.Value.(yaml.MapSlice); ok {
+			for _, l := range limits {
+				n, _ := strconv.ParseInt(fmt.Sprint(l.Value), 10, 64)
+				if fmt.Sprint(l.Key) == "soft" {
+					soft = n
+				} else {
+					hard = n
+				}
+			}
+		} else {
+			n, err := strconv.ParseInt(fmt.Sprint(item.Value), 10, 64)
+			if err != nil {
+				c.warn("service '%s': ulimit '%v' is invalid and ignored", name, item.Key)
+				continue
+			}
+			soft, hard = n, n
+		}
+
+		ulimits = append(ulimits, yaml.MapItem{
+			Key:   item.Key,
+			Value: yaml.MapSlice{{Key: "soft", Value: soft}, {Key: "hard", Value: hard}},
+		})
+	}
+
+	return ulimits
+}
+
+func (c *composeConverter) setMemory(con map[string]interface{}, name string, target string, key string, value interface{}) {
+
+	mib, err := parseComposeMemory(fmt.Sprint(value))
+	if err != nil {
+		c.warn("service '%s': %s '%v' is invalid and ignored", name, key, value)
+		return
+	}
+	con[target] = mib
+}
+
+func (c *composeConverter) setInt(con map[string]interface{}, name string, target string, key string, value interface{}) {
+
+	n, err := strconv.ParseInt(fmt.Sprint(value), 10, 64)
+	if err != nil {
+		c.warn("service '%s': %s '%v' is invalid and ignored", name, key, value)
+		return
+	}
+	con[target] = n
+}
+
+func (c *composeConverter) setCPUs(con map[string]interface{}, name string, key string, value interface{}) {
+
+	cpus, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+	if err != nil {
+		c.warn("service '%s': %s '%v' is invalid and ignored", name, key, value)
+		return
+	}
+	con["cpu_units"] = int64(cpus * 1024)
+}
+
+// parseComposeMemory converts memory of docker-compose, like "512m" and "1g", into MiB.
+func parseComposeMemory(value string) (int64, error) {
+
+	tokens := composeMemoryPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if tokens == nil {
+		return 0, fmt.Errorf("invalid memory '%s'", value)
+	}
+
+	n, err := strconv.ParseFloat(tokens[1], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	bytes := map[string]float64{
+		"":  1,
+		"b": 1,
+		"k": 1024,
+		"m": 1024 * 1024,
+		"g": 1024 * 1024 * 1024,
+	}[strings.ToLower(tokens[2])]
+
+	return int64(math.Ceil(n * bytes / (1024 * 1024))), nil
+}
+
+func composeStrings(value interface{}) []string {
+
+	switch v := value.(type) {
+	case nil:
+		return []string{}
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+func composeMap(value interface{}) map[string]string {
+
+	values := map[string]string{}
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for _, item := range v {
+			if item.Value == nil {
+				values[fmt.Sprint(item.Key)] = ""
+			} else {
+				values[fmt.Sprint(item.Key)] = fmt.Sprint(item.Value)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			tokens := strings.SplitN(fmt.Sprint(item), "=", 2)
+			if len(tokens) == 2 {
+				values[tokens[0]] = tokens[1]
+			} else {
+				values[tokens[0]] = ""
+			}
+		}
+	}
+
+	return values
+}
+
+// orderContainerKeys orders keys of container as the fields of types.ContainerDefinition.
+func orderContainerKeys(con map[string]interface{}) yaml.MapSlice {
+
+	ordered := yaml.MapSlice{}
+	t := reflect.TypeOf(types.ContainerDefinition{})
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if value, ok := con[key]; ok && key != "" {
+			ordered = append(ordered, yaml.MapItem{Key: key, Value: value})
+		}
+	}
+
+	return ordered
+}
+
+// composeExportVersion supports resource limits of containers without swarm mode.
+const composeExportVersion = "2.4"
+
+// ecsOnlyLogDrivers do not work on local docker.
+var ecsOnlyLogDrivers = map[string]bool{
+	"awslogs":     true,
+	"awsfirelens": true,
+}
+
+// ExportCompose converts resolved task definition into docker-compose file to run it on local docker.
+// Secret values are replaced with placeholders, and ECS only features are dropped with warnings.
+func ExportCompose(task *types.TaskDefinition) (string, []string, error) {
+
+	c := &composeConverter{
+		warnings: []string{},
+	}
+
+	names := []string{}
+	for name := range task.ContainerDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := yaml.MapSlice{}
+	for _, name := range names {
+		service, err := c.exportContainer(task.ContainerDefinitions[name], task.Volumes)
+		if err != nil {
+			return "", []string{}, fmt.Errorf("container '%s': %v", name, err)
+		}
+		services = append(services, yaml.MapItem{Key: name, Value: service})
+	}
+
+	compose := yaml.MapSlice{
+		{Key: "version", Value: composeExportVersion},
+		{Key: "services", Value: services},
+	}
+	if volumes := c.exportVolumes(task.Volumes); len(volumes) > 0 {
+		compose = append(compose, yaml.MapItem{Key: "volumes", Value: volumes})
+	}
+
+	out, err := yaml.Marshal(compose)
+	if err != nil {
+		return "", []string{}, err
+	}
+
+	header := fmt.Sprintf("# Generated from task definition '%s' by ecs-formation.\n", task.Name)
+	return header + string(out), c.warnings, nil
+}
+
+// exportVolumes converts task-level volumes except host paths into named volumes.
+// EFS is replaced with local volume, since it cannot be mounted locally.
+func (c *composeConverter) exportVolumes(taskVolumes map[string]types.TaskVolume) map[string]yaml.MapSlice {
+
+	volumes := map[string]yaml.MapSlice{}
+	for name, volume := range taskVolumes {
+		if volume.HostPath != "" {
+			continue
+		}
+
+		conf := yaml.MapSlice{}
+		if volume.EFS != nil {
+			c.warn("volume '%s': EFS works only on ECS, so local volume is used", name)
+		}
+		if volume.Driver != "" {
+			conf = append(conf, yaml.MapItem{Key: "driver", Value: volume.Driver})
+		}
+		if len(volume.DriverOpts) > 0 {
+			conf = append(conf, yaml.MapItem{Key: "driver_opts", Value: volume.DriverOpts})
+		}
+		if len(volume.Labels) > 0 {
+			conf = append(conf, yaml.MapItem{Key: "labels", Value: volume.Labels})
+		}
+		volumes[name] = conf
+	}
+
+	return volumes
+}
+
+func (c *composeConverter) exportContainer(con *types.ContainerDefinition, taskVolumes map[string]types.TaskVolume) (yaml.MapSlice, error) {
+
+	service := yaml.MapSlice{}
+	add := func(key string, value interface{}) {
+		service = append(service, yaml.MapItem{Key: key, Value: value})
+	}
+
+	add("image", escapeCompose(con.Image))
+
+	if len(con.EntryPoint) > 0 {
+		add("entrypoint", escapeComposeStrings(con.EntryPoint))
+	}
+	if len(con.Command) > 0 {
+		add("command", escapeComposeStrings(con.Command))
+	}
+
+	mappings, err := types.ToPortMappings(con.Ports)
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) > 0 {
+		ports := []string{}
+		for _, mp := range mappings {
+			port := fmt.Sprintf("%d/%s", *mp.ContainerPort, *mp.Protocol)
+			if *mp.HostPort != 0 {
+				port = fmt.Sprintf("%d:%s", *mp.HostPort, port)
+			}
+			ports = append(ports, port)
+		}
+		add("ports", ports)
+	}
+
+	if len(con.Environment) > 0 {
+		environment := map[string]string{}
+		for key, value := range con.Environment {
+			if util.MaskSecrets(value) != value {
+				c.warn("container '%s': environment '%s' is secret, so it is written as placeholder '${%s}'", con.Name, key, key)
+				environment[key] = fmt.Sprintf("${%s}", key)
+				continue
+			}
+			environment[key] = escapeCompose(value)
+		}
+		add("environment", environment)
+	}
+
+	if len(con.Links) > 0 {
+		add("links", con.Links)
+	}
+
+	volumes, err := types.CreateVolumeInfoItems(con.Volumes, taskVolumes)
+	if err != nil {
+		return nil, err
+	}
+	if len(volumes) > 0 {
+		values := []string{}
+		for _, v := range volumes {
+			source := *v.MountPoint.SourceVolume
+			if v.Volume != nil {
+				source = *v.Volume.Host.SourcePath
+			} else if hostPath := taskVolumes[source].HostPath; hostPath != "" {
+				source = hostPath
+			}
+			value := fmt.Sprintf("%s:%s", source, *v.MountPoint.ContainerPath)
+			if *v.MountPoint.ReadOnly {
+				value += ":ro"
+			}
+			values = append(values, value)
+		}
+		add("volumes", values)
+	}
+	if len(con.VolumesFrom) > 0 {
+		add("volumes_from", con.VolumesFrom)
+	}
+
+	if con.Memory != nil {
+		add("mem_limit", fmt.Sprintf("%dm", *con.Memory))
+	}
+	if con.MemoryReservation != nil {
+		add("mem_reservation", fmt.Sprintf("%dm", *con.MemoryReservation))
+	}
+	if con.CPUUnits > 0 {
+		add("cpu_shares", con.CPUUnits)
+	}
+
+	if con.DisableNetworking {
+		add("network_mode", "none")
+	}
+	if len(con.DNSServers) > 0 {
+		add("dns", con.DNSServers)
+	}
+	if len(con.DNSSearchDomains) > 0 {
+		add("dns_search", con.DNSSearchDomains)
+	}
+	if len(con.DockerLabels) > 0 {
+		labels := map[string]string{}
+		for key, value := range con.DockerLabels {
+			labels[key] = escapeCompose(value)
+		}
+		add("labels", labels)
+	}
+	if len(con.DockerSecurityOptions) > 0 {
+		add("security_opt", con.DockerSecurityOptions)
+	}
+	if len(con.ExtraHosts) > 0 {
+		add("extra_hosts", con.ExtraHosts)
+	}
+	if con.Hostname != "" {
+		add("hostname", con.Hostname)
+	}
+
+	if ecsOnlyLogDrivers[con.LogDriver] {
+		c.warn("container '%s': log driver '%s' works only on ECS, so default log driver is used", con.Name, con.LogDriver)
+	} else if con.LogDriver != "" {
+		logging := yaml.MapSlice{{Key: "driver", Value: con.LogDriver}}
+		if len(con.LogOpt) > 0 {
+			logging = append(logging, yaml.MapItem{Key: "options", Value: con.LogOpt})
+		}
+		add("logging", logging)
+		if len(con.LogSecretOptions) > 0 {
+			c.warn("container '%s': log_secret_options are read from AWS only on ECS, so they are ignored", con.Name)
+		}
+	}
+
+	if con.Privileged {
+		add("privileged", true)
+	}
+	if con.ReadonlyRootFilesystem {
+		add("read_only", true)
+	}
+	if len(con.Ulimits) > 0 {
+		ulimits := map[string]yaml.MapSlice{}
+		for name, limit := range con.Ulimits {
+			ulimits[name] = yaml.MapSlice{{Key: "soft", Value: limit.Soft}, {Key: "hard", Value: limit.Hard}}
+		}
+		add("ulimits", ulimits)
+	}
+	if con.User != "" {
+		add("user", con.User)
+	}
+	if con.WorkingDirectory != "" {
+		add("working_dir", con.WorkingDirectory)
+	}
+
+	if len(con.CapAdd) > 0 {
+		add("cap_add", con.CapAdd)
+	}
+	if len(con.CapDrop) > 0 {
+		add("cap_drop", con.CapDrop)
+	}
+	if con.Init != nil {
+		add("init", *con.Init)
+	}
+	if con.ShmSize != nil {
+		add("shm_size", fmt.Sprintf("%dm", *con.ShmSize))
+	}
+	if len(con.Tmpfs) > 0 {
+		items, err := types.ToTmpfsItems(con.Tmpfs)
+		if err != nil {
+			return nil, err
+		}
+		tmpfs := []string{}
+		for _, item := range items {
+			options := append([]string{fmt.Sprintf("size=%dm", *item.Size)}, aws.StringValueSlice(item.MountOptions)...)
+			tmpfs = append(tmpfs, fmt.Sprintf("%s:%s", *item.ContainerPath, strings.Join(options, ",")))
+		}
+		add("tmpfs", tmpfs)
+	}
+	if len(con.Devices) > 0 {
+		add("devices", con.Devices)
+	}
+	if len(con.Sysctls) > 0 {
+		add("sysctls", con.Sysctls)
+	}
+	if con.StopTimeout != nil {
+		add("stop_grace_period", fmt.Sprintf("%ds", *con.StopTimeout))
+	}
+	if con.StartTimeout != nil {
+		c.warn("container '%s': start_timeout works only on ECS and is ignored", con.Name)
+	}
+	if hc := con.HealthCheck; hc != nil {
+		healthcheck := yaml.MapSlice{{Key: "test", Value: escapeComposeStrings(hc.Command)}}
+		for _, item := range []struct {
+			key   string
+			value *int64
+		}{{"interval", hc.Interval}, {"timeout", hc.Timeout}, {"start_period", hc.StartPeriod}} {
+			if item.value != nil {
+				healthcheck = append(healthcheck, yaml.MapItem{Key: item.key, Value: fmt.Sprintf("%ds", *item.value)})
+			}
+		}
+		if hc.Retries != nil {
+			healthcheck = append(healthcheck, yaml.MapItem{Key: "retries", Value: *hc.Retries})
+		}
+		add("healthcheck", healthcheck)
+	}
+	if con.Tty {
+		add("tty", true)
+	}
+	if con.StdinOpen {
+		add("stdin_open", true)
+	}
+	if con.RepositoryCredentials != "" {
+		c.warn("container '%s': repository_credentials works only on ECS. Log in to the registry with 'docker login'", con.Name)
+	}
+	if con.ResourceRequirements != nil {
+		c.warn("container '%s': resource_requirements works only on ECS and is ignored", con.Name)
+	}
+	if con.FireLens != nil {
+		c.warn("container '%s': firelens works only on ECS, so logs of other containers are not routed to it", con.Name)
+	}
+
+	return service, nil
+}
+
+// escapeCompose escapes '$', since docker-compose interpolates variables.
+func escapeCompose(value string) string {
+	return strings.Replace(value, "$", "$$", -1)
+}
+
+func escapeComposeStrings(values []string) []string {
+
+	escaped := []string{}
+	for _, value := range values {
+		escaped = append(escaped, escapeCompose(value))
+	}
+
+	return escaped
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/openfresh/ecs-formation/service/types"
+	"github.com/openfresh/ecs-formation/util"
+)
+
+// containsWarning checks one of warnings has substr.
+func containsWarning(warnings []string, substr string) bool {
+
+	for _, warning := range warnings {
+		if strings.Contains(warning, substr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestImportCompose(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		compose  string
+		expect   string
+		warnings []string
+	}{
+		{
+			name: "shell and list command",
+			compose: `
+services:
+  web:
+    image: nginx:1.13
+    mem_limit: 128m
+    command: nginx -g 'daemon off;'
+    entrypoint: ["/docker-entrypoint.sh"]
+`,
+			expect: `web:
+  image: nginx:1.13
+  memory: 128
+  essential: true
+  entry_point:
+  - /docker-entrypoint.sh
+  command: nginx -g 'daemon off;'
+`,
+		},
+		{
+			name: "short and long syntax ports",
+			compose: `
+services:
+  web:
+    image: nginx:1.13
+    mem_reservation: 64m
+    ports:
+      - "80"
+      - "8080:80"
+      - "127.0.0.1:8443:443"
+      - target: 53
+        published: 5353
+        protocol: udp
+      - "http"
+`,
+			expect: `web:
+  image: nginx:1.13
+  ports:
+  - :80
+  - 8080:80
+  - 8443:443
+  - 5353:53/udp
+  memory_reservation: 64
+  essential: true
+`,
+			warnings: []string{
+				"host IP '127.0.0.1' of port '127.0.0.1:8443:443' is ignored by ECS",
+				"port ':http' is invalid and ignored",
+			},
+		},
+		{
+			name: "short and long syntax volumes",
+			compose: `
+volumes:
+  data:
+services:
+  db:
+    image: mysql:5.7
+    mem_limit: 1g
+    volumes:
+      - data:/var/lib/mysql
+      - /etc/mysql/conf.d:/etc/mysql/conf.d:ro
+      - ./init:/docker-entrypoint-initdb.d
+      - /tmp
+      - type: bind
+        source: /var/log/mysql
+        target: /var/log/mysql
+        read_only: true
+      - type: tmpfs
+        target: /run
+      - cache:/cache
+`,
+			expect: `db:
+  image: mysql:5.7
+  volumes:
+  - data:/var/lib/mysql
+  - /etc/mysql/conf.d:/etc/mysql/conf.d:ro
+  - ./init:/docker-entrypoint-initdb.d
+  - /var/log/mysql:/var/log/mysql:ro
+  memory: 1024
+  essential: true
+volumes:
+  data:
+    driver: local
+    scope: shared
+    autoprovision: true
+`,
+			warnings: []string{
+				"host path './init' is relative",
+				"anonymous volume '/tmp' is not translated",
+				"volume of type 'tmpfs' is not translated",
+				"volume 'cache' is not declared in top-level volumes and ignored",
+			},
+		},
+		{
+			name: "environment and warnings",
+			compose: `
+version: "3"
+networks:
+  front:
+services:
+  api:
+    image: app:1.0
+    build: .
+    depends_on:
+      - db
+    environment:
+      - APP_ENV=production
+      - SECRET
+    healthcheck:
+      test: curl -f http://localhost/
+      interval: 30s
+`,
+			expect: `api:
+  image: app:1.0
+  environment:
+    APP_ENV: production
+    SECRET: ${SECRET}
+  essential: true
+  health_check:
+    command: curl -f http://localhost/
+    interval: 30
+`,
+			warnings: []string{
+				"top-level 'networks' is not translated",
+				"service 'api': 'build' is not supported and ignored",
+				"service 'api': depends_on is not translated",
+				"environment 'SECRET' has no value",
+				"service 'api': memory is not limited",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		actual, warnings, err := ImportCompose([]byte(test.compose))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		if actual != test.expect {
+			t.Errorf("%s: actual task file is\n%s", test.name, actual)
+		}
+
+		if len(warnings) != len(test.warnings) {
+			t.Errorf("%s: expect %d warnings, but actual is %v", test.name, len(test.warnings), warnings)
+		}
+		for _, warning := range test.warnings {
+			if !containsWarning(warnings, warning) {
+				t.Errorf("%s: expect warning '%s', but actual is %v", test.name, warning, warnings)
+			}
+		}
+
+		td, err := types.CreateTaskDefinition(test.name, actual, ".")
+		if err != nil {
+			t.Errorf("%s: task file cannot be loaded: %v", test.name, err)
+			continue
+		}
+		for name, con := range td.ContainerDefinitions {
+			if _, _, err := types.CreateContainerDefinition(con, td.Volumes); err != nil {
+				t.Errorf("%s: container '%s' cannot be converted: %v", test.name, name, err)
+			}
+		}
+	}
+}
+
+func TestImportInvalidCompose(t *testing.T) {
+
+	composes := []string{
+		"web:\n  image: nginx\n",
+		"services: [web]\n",
+		"services:\n  web: nginx\n",
+		"services: {\n",
+	}
+
+	for _, compose := range composes {
+		if _, _, err := ImportCompose([]byte(compose)); err == nil {
+			t.Errorf("expect error of %q, but actual is nil", compose)
+		}
+	}
+}
+
+func TestExportCompose(t *testing.T) {
+
+	util.RegisterSecret("s3cr3t-password")
+
+	task := &types.TaskDefinition{
+		Name: "api",
+		ContainerDefinitions: map[string]*types.ContainerDefinition{
+			"api": {
+				Name:    "api",
+				Image:   "app:1.0",
+				Command: types.CommandLine{"sh", "-c", "echo $HOME"},
+				Ports:   []string{"8080:80", ":9000"},
+				Environment: map[string]string{
+					"DB_PASSWORD": "s3cr3t-password",
+					"PRICE":       "$5",
+				},
+				Volumes:      []string{"data:/data", "logs:/logs:ro", "/etc/app:/etc/app"},
+				Memory:       aws.Int64(256),
+				LogDriver:    "awslogs",
+				LogOpt:       map[string]string{"awslogs-group": "/ecs/api"},
+				StartTimeout: aws.Int64(60),
+				HealthCheck: &types.HealthCheck{
+					Command:  types.HealthCheckCommand{"CMD-SHELL", "curl -f http://localhost/"},
+					Interval: aws.Int64(30),
+					Retries:  aws.Int64(3),
+				},
+			},
+			"router": {
+				Name:      "router",
+				Image:     "fluent/fluentd:v1",
+				LogDriver: "fluentd",
+				LogOpt:    map[string]string{"tag": "router"},
+			},
+		},
+		Volumes: map[string]types.TaskVolume{
+			"data": {Driver: "local"},
+			"logs": {HostPath: "/var/log/app"},
+		},
+	}
+
+	expect := `# Generated from task definition 'api' by ecs-formation.
+version: "2.4"
+services:
+  api:
+    image: app:1.0
+    command:
+    - sh
+    - -c
+    - echo $$HOME
+    ports:
+    - 8080:80/tcp
+    - 9000/tcp
+    environment:
+      DB_PASSWORD: ${DB_PASSWORD}
+      PRICE: $$5
+    volumes:
+    - data:/data
+    - /var/log/app:/logs:ro
+    - /etc/app:/etc/app
+    mem_limit: 256m
+    healthcheck:
+      test:
+      - CMD-SHELL
+      - curl -f http://localhost/
+      interval: 30s
+      retries: 3
+  router:
+    image: fluent/fluentd:v1
+    logging:
+      driver: fluentd
+      options:
+        tag: router
+volumes:
+  data:
+    driver: local
+`
+
+	actual, warnings, err := ExportCompose(task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if actual != expect {
+		t.Errorf("actual compose file is\n%s", actual)
+	}
+
+	expectWarnings := []string{
+		"container 'api': environment 'DB_PASSWORD' is secret, so it is written as placeholder '${DB_PASSWORD}'",
+		"container 'api': log driver 'awslogs' works only on ECS",
+		"container 'api': start_timeout works only on ECS",
+	}
+	if len(warnings) != len(expectWarnings) {
+		t.Errorf("expect %d warnings, but actual is %v", len(expectWarnings), warnings)
+	}
+	for _, warning := range expectWarnings {
+		if !containsWarning(warnings, warning) {
+			t.Errorf("expect warning '%s', but actual is %v", warning, warnings)
+		}
+	}
+
+	if strings.Contains(actual, "s3cr3t-password") || strings.Contains(actual, "awslogs") {
+		t.Errorf("expect secret and awslogs are not exported, but actual is\n%s", actual)
+	}
+}
+
+func TestExportComposeWithEFS(t *testing.T) {
+
+	task := &types.TaskDefinition{
+		Name: "api",
+		ContainerDefinitions: map[string]*types.ContainerDefinition{
+			"api": {Name: "api", Image: "app:1.0", Volumes: []string{"shared:/shared"}},
+		},
+		Volumes: map[string]types.TaskVolume{
+			"shared": {EFS: &types.EFSVolume{FileSystemID: "fs-12345678"}},
+		},
+	}
+
+	actual, warnings, err := ExportCompose(task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasSuffix(actual, "volumes:\n  shared: {}\n") {
+		t.Errorf("expect EFS is local volume, but actual is\n%s", actual)
+	}
+	if !containsWarning(warnings, "volume 'shared': EFS works only on ECS") {
+		t.Errorf("expect warning of EFS, but actual is %v", warnings)
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/openfresh/ecs-formation/client"
+	"github.com/openfresh/ecs-formation/client/s3"
+	"github.com/openfresh/ecs-formation/client/ssm"
+	"github.com/openfresh/ecs-formation/service/types"
+	"github.com/openfresh/ecs-formation/util"
+)
+
+const ssmEnvFilePrefix = "ssm:"
+
+// s3HostPattern matches hosts of S3, and the first group is bucket in virtual-hosted style.
+var s3HostPattern = regexp.MustCompile(`^(?:(.+)\.)?s3(?:[.-][a-z0-9-]+)*\.amazonaws\.com(?:\.cn)?$`)
+
+// RegisterEnvFileSources registers sources of env files on AWS.
+func RegisterEnvFileSources(awscli client.AWSClient) {
+	types.RegisterEnvFileSource(S3EnvFileSource{s3Cli: awscli.S3})
+	types.RegisterEnvFileSource(SSMEnvFileSource{ssmCli: awscli.SSM})
+}
+
+// S3EnvFileSource reads env file of 's3://bucket/key', or URL of S3 in path style or virtual-hosted style.
+type S3EnvFileSource struct {
+	s3Cli s3.Client
+}
+
+func (s S3EnvFileSource) Match(path string) bool {
+	_, _, ok := parseS3URL(path)
+	return ok || strings.HasPrefix(path, "s3://")
+}
+
+func (s S3EnvFileSource) Read(path string, basedir string) (map[string]string, error) {
+
+	bucket, key, ok := parseS3URL(path)
+	if !ok {
+		return map[string]string{}, fmt.Errorf("'%s' should be 's3://bucket/key'", path)
+	}
+
+	obj, err := s.s3Cli.GetObject(bucket, key)
+	if err != nil {
+		return map[string]string{}, err
+	}
+	defer obj.Body.Close()
+
+	content, err := ioutil.ReadAll(obj.Body)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	return types.ParseEnvFile(content)
+}
+
+// parseS3URL returns bucket and key of S3 URL.
+func parseS3URL(path string) (string, string, bool) {
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", "", false
+	}
+
+	var bucket, key string
+	switch u.Scheme {
+	case "s3":
+		bucket, key = u.Host, strings.TrimPrefix(u.Path, "/")
+	case "http", "https":
+		tokens := s3HostPattern.FindStringSubmatch(u.Host)
+		if tokens == nil {
+			return "", "", false
+		}
+		if tokens[1] != "" {
+			bucket, key = tokens[1], strings.TrimPrefix(u.Path, "/")
+		} else {
+			parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+			if len(parts) == 2 {
+				bucket, key = parts[0], parts[1]
+			}
+		}
+	default:
+		return "", "", false
+	}
+
+	return bucket, key, bucket != "" && key != ""
+}
+
+// SSMEnvFileSource reads all parameters under path of 'ssm:/path/prefix' as variables.
+// Variable name is the parameter name relative to the prefix, and '/' in it is replaced with '_'.
+// Values of SecureString are secret.
+type SSMEnvFileSource struct {
+	ssmCli ssm.Client
+}
+
+func (s SSMEnvFileSource) Match(path string) bool {
+	return strings.HasPrefix(path, ssmEnvFilePrefix)
+}
+
+func (s SSMEnvFileSource) Read(path string, basedir string) (map[string]string, error) {
+
+	prefix := strings.TrimSuffix(strings.TrimPrefix(path, ssmEnvFilePrefix), "/")
+	if !strings.HasPrefix(prefix, "/") {
+		return map[string]string{}, fmt.Errorf("'%s' should be 'ssm:/path/prefix'", path)
+	}
+
+	params, err := s.ssmCli.GetParametersByPath(prefix, true)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	envmap := map[string]string{}
+	for _, param := range params {
+		name := strings.TrimPrefix(strings.TrimPrefix(*param.Name, prefix), "/")
+		envmap[strings.Replace(name, "/", "_", -1)] = *param.Value
+		if *param.Type == "SecureString" {
+			util.RegisterSecret(*param.Value)
+		}
+	}
+
+	return envmap, nil
+}
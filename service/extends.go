@@ -0,0 +1,199 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/openfresh/ecs-formation/util"
+)
+
+const extendsKey = "extends"
+
+// extendsResolver resolves 'extends: {file, name}' of containers in task files and services in cluster files.
+// Relative file is looked up from the directory of the file, and then '_shared' directory of project.
+type extendsResolver struct {
+	sharedDir string
+	params    map[string]string
+	docs      map[string]map[interface{}]interface{}
+	used      []string
+}
+
+func newExtendsResolver(projectDir string, params map[string]string) *extendsResolver {
+	return &extendsResolver{
+		sharedDir: filepath.Join(projectBaseDir(projectDir), "_shared"),
+		params:    params,
+		docs:      map[string]map[interface{}]interface{}{},
+		used:      []string{},
+	}
+}
+
+// resolveExtends resolves 'extends' of all entries in file, and replaces content of file.
+func resolveExtends(projectDir string, file *yamlFile, params map[string]string) error {
+
+	doc := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal([]byte(file.Content), &doc); err != nil {
+		return fmt.Errorf("%s: %v", file.Path, err)
+	}
+
+	if !hasExtends(doc) {
+		return nil
+	}
+
+	r := newExtendsResolver(projectDir, params)
+	r.docs[file.Path] = doc
+
+	resolved := map[interface{}]interface{}{}
+	for key, value := range doc {
+		name := fmt.Sprint(key)
+		entry, err := r.resolve(file.Path, name, value, []string{extendsRef(file.Path, name)})
+		if err != nil {
+			return err
+		}
+		resolved[key] = entry
+	}
+
+	content, err := yaml.Marshal(resolved)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file.Path, err)
+	}
+
+	file.Content = string(content)
+	file.Parameters = unionStrings(file.Parameters, r.used)
+
+	return nil
+}
+
+func hasExtends(doc map[interface{}]interface{}) bool {
+
+	for _, value := range doc {
+		if entry, ok := value.(map[interface{}]interface{}); ok {
+			if _, ok := entry[extendsKey]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (r *extendsResolver) resolve(path string, name string, value interface{}, chain []string) (interface{}, error) {
+
+	entry, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return value, nil
+	}
+
+	ext, ok := entry[extendsKey]
+	if !ok {
+		return value, nil
+	}
+
+	targetFile, targetName, err := parseExtends(ext)
+	if err != nil {
+		return nil, fmt.Errorf("%s: '%s' has invalid extends: %v", path, name, err)
+	}
+
+	targetPath := path
+	if targetFile != "" {
+		targetPath, err = r.lookupFile(path, targetFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: '%s' extends '%s': %v", path, name, targetName, err)
+		}
+	}
+
+	ref := extendsRef(targetPath, targetName)
+	for _, visited := range chain {
+		if visited == ref {
+			return nil, fmt.Errorf("Cyclic extends: %s. ", strings.Join(append(chain, ref), " -> "))
+		}
+	}
+
+	doc, err := r.loadDoc(targetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	parentValue, ok := doc[targetName]
+	if !ok {
+		return nil, fmt.Errorf("%s: '%s' extends '%s', but it is not found in %s", path, name, targetName, targetPath)
+	}
+
+	parent, err := r.resolve(targetPath, targetName, parentValue, append(chain, ref))
+	if err != nil {
+		return nil, err
+	}
+
+	child := map[interface{}]interface{}{}
+	for key, v := range entry {
+		if key != extendsKey {
+			child[key] = v
+		}
+	}
+
+	return util.MergeYamlValue(parent, child), nil
+}
+
+func parseExtends(ext interface{}) (string, string, error) {
+
+	switch value := ext.(type) {
+	case string:
+		return "", value, nil
+	case map[interface{}]interface{}:
+		file, _ := value["file"].(string)
+		name, _ := value["name"].(string)
+		if name == "" {
+			return "", "", fmt.Errorf("'name' is required")
+		}
+		return file, name, nil
+	default:
+		return "", "", fmt.Errorf("should be name or {file, name}")
+	}
+}
+
+func (r *extendsResolver) lookupFile(path string, file string) (string, error) {
+
+	if filepath.IsAbs(file) {
+		return file, nil
+	}
+
+	candidates := []string{
+		filepath.Join(filepath.Dir(path), file),
+		filepath.Join(r.sharedDir, file),
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("file '%s' is not found in %s", file, strings.Join(candidates, ", "))
+}
+
+func (r *extendsResolver) loadDoc(path string) (map[interface{}]interface{}, error) {
+
+	if doc, ok := r.docs[path]; ok {
+		return doc, nil
+	}
+
+	content, used, err := renderYamlFile(path, r.params)
+	if err != nil {
+		return nil, err
+	}
+	r.used = unionStrings(r.used, used)
+
+	doc := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	r.docs[path] = doc
+
+	return doc, nil
+}
+
+func extendsRef(path string, name string) string {
+	return fmt.Sprintf("%s#%s", path, name)
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	awsecs "github.com/aws/aws-sdk-go/service/ecs"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/openfresh/ecs-formation/client/ecs"
+	"github.com/openfresh/ecs-formation/service/types"
+)
+
+// describeServicesLimit is the maximum number of services of DescribeServices.
+const describeServicesLimit = 10
+
+// pinnedTaskDefinitionPattern finds task_definition pinned to revision in service files of any overlay.
+var pinnedTaskDefinitionPattern = regexp.MustCompile(`(?m)^\s*task_definition:\s*["']?([\w-]+:[0-9]+)["']?\s*(?:#.*)?$`)
+
+// ignoredDiffFields are fields which differ between any revisions, or are derived by ECS.
+var ignoredDiffFields = map[string]bool{
+	"TaskDefinitionArn":  true,
+	"Revision":           true,
+	"Status":             true,
+	"RegisteredAt":       true,
+	"RegisteredBy":       true,
+	"DeregisteredAt":     true,
+	"RequiresAttributes": true,
+	"Compatibilities":    true,
+}
+
+// diffTaskDefinitions compares fields of two revisions, as '~ path: from -> to', '+ path: value' and '- path: value'.
+func diffTaskDefinitions(from *awsecs.TaskDefinition, to *awsecs.TaskDefinition) ([]string, error) {
+
+	fromFields, err := flattenTaskDefinition(from)
+	if err != nil {
+		return []string{}, err
+	}
+
+	toFields, err := flattenTaskDefinition(to)
+	if err != nil {
+		return []string{}, err
+	}
+
+	paths := []string{}
+	for path := range fromFields {
+		paths = append(paths, path)
+	}
+	for path := range toFields {
+		if _, ok := fromFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	diffs := []string{}
+	for _, path := range paths {
+		before, inFrom := fromFields[path]
+		after, inTo := toFields[path]
+		switch {
+		case !inFrom:
+			diffs = append(diffs, fmt.Sprintf("+ %s: %s", path, after))
+		case !inTo:
+			diffs = append(diffs, fmt.Sprintf("- %s: %s", path, before))
+		case before != after:
+			diffs = append(diffs, fmt.Sprintf("~ %s: %s -> %s", path, before, after))
+		}
+	}
+
+	return diffs, nil
+}
+
+// flattenTaskDefinition converts task definition into values by path like 'ContainerDefinitions[api].Image'.
+// Items of lists which have 'Name', like containers and environment variables, are keyed by the name.
+func flattenTaskDefinition(td *awsecs.TaskDefinition) (map[string]string, error) {
+
+	data, err := json.Marshal(td)
+	if err != nil {
+		return nil, err
+	}
+
+	// numbers are kept as they are, since float64 prints large ones like '1e+06'.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value map[string]interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	for field := range ignoredDiffFields {
+		delete(value, field)
+	}
+
+	fields := map[string]string{}
+	flattenValue("", value, fields)
+
+	return fields, nil
+}
+
+func flattenValue(path string, value interface{}, fields map[string]string) {
+
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for key, item := range v {
+			// name of item in list is already in the path.
+			if key == "Name" && strings.HasSuffix(path, "]") {
+				continue
+			}
+			if path == "" {
+				flattenValue(key, item, fields)
+			} else {
+				flattenValue(path+"."+key, item, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			key := fmt.Sprint(i)
+			if m, ok := item.(map[string]interface{}); ok {
+				if name, ok := m["Name"].(string); ok {
+					key = name
+				}
+			}
+			flattenValue(fmt.Sprintf("%s[%s]", path, key), item, fields)
+		}
+	default:
+		fields[path] = fmt.Sprint(v)
+	}
+}
+
+// searchServiceTaskDefinitions returns task definitions which services of all clusters use, by ARN.
+// Values are the services, as 'cluster/service'. Revisions of running deployments are included.
+func searchServiceTaskDefinitions(ecsCli ecs.Client) (map[string][]string, error) {
+
+	used := map[string][]string{}
+
+	clusters, err := ecsCli.ListAllClusters()
+	if err != nil {
+		return used, err
+	}
+
+	for _, cluster := range clusters {
+		services, err := ecsCli.ListAllServices(*cluster)
+		if err != nil {
+			return used, err
+		}
+
+		for i := 0; i < len(services); i += describeServicesLimit {
+			end := i + describeServicesLimit
+			if end > len(services) {
+				end = len(services)
+			}
+
+			result, err := ecsCli.DescribeService(*cluster, services[i:end])
+			if err != nil {
+				return used, err
+			}
+
+			for _, svc := range result.Services {
+				name := fmt.Sprintf("%s/%s", clusterName(*cluster), *svc.ServiceName)
+				arns := map[string]bool{}
+				if svc.TaskDefinition != nil {
+					arns[*svc.TaskDefinition] = true
+				}
+				for _, deployment := range svc.Deployments {
+					if deployment.TaskDefinition != nil {
+						arns[*deployment.TaskDefinition] = true
+					}
+				}
+				for arn := range arns {
+					used[arn] = append(used[arn], name)
+				}
+			}
+		}
+	}
+
+	return used, nil
+}
+
+// clusterName returns name of cluster from its ARN.
+func clusterName(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
+
+// revisionOf returns revision of task definition from its ARN, like 'arn:...:task-definition/api:12'.
+func revisionOf(arn string) int64 {
+	revision, _ := strconv.ParseInt(arn[strings.LastIndex(arn, ":")+1:], 10, 64)
+	return revision
+}
+
+// searchPinnedTaskDefinitions returns revisions pinned in the project as 'family:revision', by service files
+// and lock files. Values are where they are pinned. Service files of the overlay are loaded with parameters,
+// and literal revisions in service files of other overlays are also found.
+func searchPinnedTaskDefinitions(projectDir string, overlay string, params map[string]string) (map[string][]string, error) {
+
+	pinned := map[string][]string{}
+	add := func(revision string, source string) {
+		for _, s := range pinned[revision] {
+			if s == source {
+				return
+			}
+		}
+		pinned[revision] = append(pinned[revision], source)
+	}
+
+	files, err := searchYamlFiles(projectDir, overlay, "service", params)
+	if err != nil {
+		return pinned, err
+	}
+	for _, file := range files {
+		if err := resolveExtends(projectDir, file, params); err != nil {
+			return pinned, err
+		}
+		services, err := types.CreateServiceMap(file.Content)
+		if err != nil {
+			return pinned, err
+		}
+		for name, service := range services {
+			if revision, ok := service.PinnedRevision(); ok {
+				family, _ := types.SplitTaskDefinition(service.TaskDefinition)
+				add(fmt.Sprintf("%s:%d", family, revision), fmt.Sprintf("service '%s/%s'", file.Name, name))
+			}
+		}
+	}
+
+	dirs := []string{filepath.Join(projectBaseDir(projectDir), "service")}
+	overlayDirs, _ := filepath.Glob(filepath.Join(projectDir, "overlays", "*", "service"))
+	dirs = append(dirs, overlayDirs...)
+	for _, dir := range dirs {
+		paths, err := walkYamlFiles(dir)
+		if err != nil {
+			return pinned, err
+		}
+		for _, path := range paths {
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return pinned, err
+			}
+			for _, tokens := range pinnedTaskDefinitionPattern.FindAllStringSubmatch(string(content), -1) {
+				add(tokens[1], relativeProjectPath(projectDir, path))
+			}
+		}
+	}
+
+	locks, err := filepath.Glob(filepath.Join(projectDir, "ecs-formation*.lock"))
+	if err != nil {
+		return pinned, err
+	}
+	for _, path := range locks {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return pinned, err
+		}
+		lock := types.LockFile{}
+		if err := yaml.Unmarshal(content, &lock); err != nil {
+			return pinned, fmt.Errorf("Failed to read lock file '%s': %v. ", path, err)
+		}
+		for name, task := range lock.Tasks {
+			if task != nil && task.Revision > 0 {
+				add(fmt.Sprintf("%s:%d", name, task.Revision), filepath.Base(path))
+			}
+		}
+	}
+
+	return pinned, nil
+}
+
+// relativeProjectPath returns path relative to project, or the path if it is outside of project.
+func relativeProjectPath(projectDir string, path string) string {
+	if rel, err := filepath.Rel(projectDir, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSearchPinnedTaskDefinitions(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "ecs-formation-pinned")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"service/web.yml":                  "api:\n  task_definition: api:${API_REVISION}\nworker:\n  task_definition: worker\nbatch:\n  task_definition: batch:latest\n",
+		"overlays/prod/service/web.yml":    "api:\n  task_definition: \"api:8\" # pinned\n",
+		"overlays/staging/service/web.yml": "api:\n  task_definition: api:${STAGING_REVISION}\n",
+		"ecs-formation.lock":               "tasks:\n  api:\n    revision: 5\n    containers: {}\n  worker:\n    containers: {}\n",
+		"ecs-formation.staging.lock":       "tasks:\n  worker:\n    revision: 3\n    containers: {}\n",
+		"task/api.yml":                     "api:\n  image: nginx\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	pinned, err := searchPinnedTaskDefinitions(dir, "", map[string]string{"API_REVISION": "12"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expect := map[string][]string{
+		"api:12":   {"service 'web/api'"},
+		"api:8":    {filepath.Join("overlays", "prod", "service", "web.yml")},
+		"api:5":    {"ecs-formation.lock"},
+		"worker:3": {"ecs-formation.staging.lock"},
+	}
+	if !reflect.DeepEqual(pinned, expect) {
+		t.Errorf("expect %v, but actual is %v", expect, pinned)
+	}
+}
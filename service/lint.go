@@ -0,0 +1,344 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/openfresh/ecs-formation/service/types"
+)
+
+const (
+	lintConfigFile  = "lint.yml"
+	lintSeverityOff = "off"
+)
+
+var lintIgnorePattern = regexp.MustCompile(`#\s*ecs-formation:ignore(-file)?(?:\s+([\w,-]+))?`)
+
+type LintRule struct {
+	ID          string
+	Severity    string
+	Description string
+	task        func(containers map[string]types.ContainerDefinition) []lintFinding
+	service     func(service types.Service) []lintFinding
+}
+
+type lintFinding struct {
+	Message string
+	Keys    []string
+}
+
+// lintConfig is 'lint.yml' in project to override severity of rules.
+type lintConfig struct {
+	Rules map[string]string `yaml:"rules"`
+}
+
+// LintRules are rules checked by validate.
+var LintRules = []*LintRule{
+	{
+		ID:          "no-essential",
+		Severity:    types.SeverityError,
+		Description: "Task definition has no essential container.",
+		task:        lintNoEssential,
+	},
+	{
+		ID:          "no-memory",
+		Severity:    types.SeverityError,
+		Description: "Container has neither memory nor memory_reservation.",
+		task:        lintNoMemory,
+	},
+	{
+		ID:          "privileged",
+		Severity:    types.SeverityWarning,
+		Description: "Container runs with privileged: true.",
+		task:        lintPrivileged,
+	},
+	{
+		ID:          "latest-tag",
+		Severity:    types.SeverityWarning,
+		Description: "Image has ':latest' tag or no tag.",
+		task:        lintLatestTag,
+	},
+	{
+		ID:          "duplicate-host-port",
+		Severity:    types.SeverityError,
+		Description: "The same host port is mapped more than once in task definition.",
+		task:        lintDuplicateHostPort,
+	},
+	{
+		ID:          "deployment-deadlock",
+		Severity:    types.SeverityError,
+		Description: "Service has minimum_healthy_percent: 100 and maximum_percent: 100, so deployment cannot start new tasks.",
+		service:     lintDeploymentDeadlock,
+	},
+}
+
+func (v *projectValidator) lint() {
+
+	severities := v.loadLintConfig()
+
+	for _, rule := range LintRules {
+		severity := rule.Severity
+		if s, ok := severities[rule.ID]; ok {
+			severity = s
+		}
+		if severity == lintSeverityOff {
+			continue
+		}
+
+		if rule.task != nil {
+			for _, name := range sortedKeys(v.taskFiles) {
+				for _, finding := range rule.task(v.tasks[name]) {
+					v.addFinding(v.taskFiles[name], rule, severity, finding)
+				}
+			}
+		}
+
+		if rule.service != nil {
+			for _, cluster := range sortedKeys(v.clusterFiles) {
+				services := v.clusters[cluster]
+				names := []string{}
+				for name := range services {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+
+				for _, name := range names {
+					service := services[name]
+					service.Name = name
+					for _, finding := range rule.service(service) {
+						finding.Keys = append([]string{name}, finding.Keys...)
+						v.addFinding(v.clusterFiles[cluster], rule, severity, finding)
+					}
+				}
+			}
+		}
+	}
+}
+
+// loadLintConfig returns severities of rules configured in 'lint.yml'.
+func (v *projectValidator) loadLintConfig() map[string]string {
+
+	path := filepath.Join(v.projectDir, lintConfigFile)
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}
+	}
+	if err != nil {
+		v.addError(path, err)
+		return map[string]string{}
+	}
+
+	conf := lintConfig{}
+	if err := yaml.UnmarshalStrict(content, &conf); err != nil {
+		v.addError(path, err)
+		return map[string]string{}
+	}
+
+	for id, severity := range conf.Rules {
+		if findLintRule(id) == nil {
+			v.add(path, fmt.Sprintf("unknown lint rule '%s'", id))
+		}
+		if severity != types.SeverityError && severity != types.SeverityWarning && severity != lintSeverityOff {
+			v.add(path, fmt.Sprintf("severity of '%s' should be error, warning or off", id))
+		}
+	}
+
+	return conf.Rules
+}
+
+func (v *projectValidator) addFinding(file *yamlFile, rule *LintRule, severity string, finding lintFinding) {
+
+	path, line := v.locate(file, finding.Keys...)
+	if isLintIgnored(path, line, rule.ID) {
+		return
+	}
+
+	v.errors = append(v.errors, &types.ValidationError{
+		File:     v.relativePath(path),
+		Line:     line,
+		Message:  finding.Message,
+		Rule:     rule.ID,
+		Severity: severity,
+	})
+}
+
+// isLintIgnored checks '# ecs-formation:ignore [rule,...]' on the line or the previous line,
+// and '# ecs-formation:ignore-file [rule,...]' in the file.
+func isLintIgnored(path string, line int, id string) bool {
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	for i, text := range strings.Split(string(content), "\n") {
+		tokens := lintIgnorePattern.FindStringSubmatch(text)
+		if tokens == nil {
+			continue
+		}
+
+		matched := tokens[2] == ""
+		for _, ignored := range strings.Split(tokens[2], ",") {
+			if ignored == id {
+				matched = true
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		if tokens[1] != "" || (line > 0 && (i+1 == line || i+2 == line)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func findLintRule(id string) *LintRule {
+	for _, rule := range LintRules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+func lintNoEssential(containers map[string]types.ContainerDefinition) []lintFinding {
+
+	if len(containers) == 0 {
+		return []lintFinding{}
+	}
+
+	for _, con := range containers {
+		if con.Essential {
+			return []lintFinding{}
+		}
+	}
+
+	names := sortedContainerNames(containers)
+	return []lintFinding{{
+		Message: "no container is essential",
+		Keys:    []string{names[0]},
+	}}
+}
+
+func lintNoMemory(containers map[string]types.ContainerDefinition) []lintFinding {
+
+	findings := []lintFinding{}
+	for _, name := range sortedContainerNames(containers) {
+		con := containers[name]
+		if con.Memory == nil && con.MemoryReservation == nil {
+			findings = append(findings, lintFinding{
+				Message: fmt.Sprintf("container '%s' has neither memory nor memory_reservation", name),
+				Keys:    []string{name},
+			})
+		}
+	}
+
+	return findings
+}
+
+func lintPrivileged(containers map[string]types.ContainerDefinition) []lintFinding {
+
+	findings := []lintFinding{}
+	for _, name := range sortedContainerNames(containers) {
+		if containers[name].Privileged {
+			findings = append(findings, lintFinding{
+				Message: fmt.Sprintf("container '%s' is privileged", name),
+				Keys:    []string{name, "privileged"},
+			})
+		}
+	}
+
+	return findings
+}
+
+func lintLatestTag(containers map[string]types.ContainerDefinition) []lintFinding {
+
+	findings := []lintFinding{}
+	for _, name := range sortedContainerNames(containers) {
+		image := containers[name].Image
+		if isUnresolved(image) {
+			continue
+		}
+
+		ref, err := types.ParseImageReference(image)
+		// invalid images are reported by validateContainer, and digests are fixed.
+		if err != nil || ref.Digest != "" {
+			continue
+		}
+
+		if ref.Tag == "latest" {
+			findings = append(findings, lintFinding{
+				Message: fmt.Sprintf("container '%s' uses image '%s' without a fixed tag", name, image),
+				Keys:    []string{name, "image"},
+			})
+		}
+	}
+
+	return findings
+}
+
+func lintDuplicateHostPort(containers map[string]types.ContainerDefinition) []lintFinding {
+
+	findings := []lintFinding{}
+	used := map[string]string{}
+	for _, name := range sortedContainerNames(containers) {
+		mappings, err := types.ToPortMappings(containers[name].Ports)
+		if err != nil {
+			continue
+		}
+
+		for _, mapping := range mappings {
+			if mapping.HostPort == nil || *mapping.HostPort == 0 {
+				continue
+			}
+
+			port := fmt.Sprintf("%d/%s", *mapping.HostPort, aws.StringValue(mapping.Protocol))
+			if other, ok := used[port]; ok {
+				findings = append(findings, lintFinding{
+					Message: fmt.Sprintf("host port %s of container '%s' is already mapped by container '%s'", port, name, other),
+					Keys:    []string{name, "ports"},
+				})
+				continue
+			}
+			used[port] = name
+		}
+	}
+
+	return findings
+}
+
+func lintDeploymentDeadlock(service types.Service) []lintFinding {
+
+	min := service.MinimumHealthyPercent
+	max := service.MaximumPercent
+	if min.Valid && max.Valid && min.Int64 == 100 && max.Int64 == 100 {
+		return []lintFinding{{
+			Message: fmt.Sprintf("service '%s' cannot deploy with minimum_healthy_percent: 100 and maximum_percent: 100", service.Name),
+			Keys:    []string{"maximum_percent"},
+		}}
+	}
+
+	return []lintFinding{}
+}
+
+func sortedKeys(files map[string]*yamlFile) []string {
+
+	keys := []string{}
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/openfresh/ecs-formation/service/types"
+)
+
+func findingMessages(findings []lintFinding) []string {
+
+	messages := []string{}
+	for _, finding := range findings {
+		messages = append(messages, finding.Message)
+	}
+
+	return messages
+}
+
+func TestLintNoEssential(t *testing.T) {
+
+	findings := lintNoEssential(map[string]types.ContainerDefinition{
+		"web": {},
+		"api": {},
+	})
+	if len(findings) != 1 || findings[0].Keys[0] != "api" {
+		t.Errorf("expect a finding on 'api', but actual is %v", findings)
+	}
+
+	findings = lintNoEssential(map[string]types.ContainerDefinition{
+		"web": {},
+		"api": {Essential: true},
+	})
+	if len(findings) != 0 {
+		t.Errorf("expect no findings, but actual is %v", findingMessages(findings))
+	}
+
+	if findings := lintNoEssential(map[string]types.ContainerDefinition{}); len(findings) != 0 {
+		t.Errorf("expect no findings of empty task, but actual is %v", findingMessages(findings))
+	}
+}
+
+func TestLintNoMemory(t *testing.T) {
+
+	findings := lintNoMemory(map[string]types.ContainerDefinition{
+		"api":    {Memory: aws.Int64(256)},
+		"worker": {MemoryReservation: aws.Int64(128)},
+		"web":    {},
+	})
+	if len(findings) != 1 || findings[0].Keys[0] != "web" {
+		t.Errorf("expect a finding on 'web', but actual is %v", findingMessages(findings))
+	}
+}
+
+func TestLintPrivileged(t *testing.T) {
+
+	findings := lintPrivileged(map[string]types.ContainerDefinition{
+		"api":   {},
+		"agent": {Privileged: true},
+	})
+	if len(findings) != 1 || findings[0].Keys[0] != "agent" || findings[0].Keys[1] != "privileged" {
+		t.Errorf("expect a finding on 'agent.privileged', but actual is %v", findings)
+	}
+}
+
+func TestLintLatestTag(t *testing.T) {
+
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		image  string
+		expect bool
+	}{
+		{"nginx", true},
+		{"nginx:latest", true},
+		{"nginx:1.13", false},
+		{"nginx@" + digest, false},
+		{"registry:5000/app", true},
+		{"registry:5000/app:latest", true},
+		{"registry:5000/app:2.0", false},
+		{"localhost:5000/team/app", true},
+		{"123456789012.dkr.ecr.ap-northeast-1.amazonaws.com/api:1.0", false},
+		{"nginx:", false},
+		{"app:${ssm:/app/tag}", false},
+		{"nginx:${file:./VERSION}", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		findings := lintLatestTag(map[string]types.ContainerDefinition{
+			"api": {Image: test.image},
+		})
+		if actual := len(findings) > 0; actual != test.expect {
+			t.Errorf("%s: expect finding is %v, but actual is %v", test.image, test.expect, findingMessages(findings))
+		}
+	}
+}
+
+func TestLintDuplicateHostPort(t *testing.T) {
+
+	findings := lintDuplicateHostPort(map[string]types.ContainerDefinition{
+		"api":    {Ports: []string{"8080:80", "53/udp"}},
+		"web":    {Ports: []string{"8080:8080", "53:53"}},
+		"worker": {Ports: []string{":80", ":80", "8000-8001:9000-9001"}},
+		"admin":  {Ports: []string{"8001:80"}},
+	})
+
+	expect := []string{
+		"host port 8080/tcp of container 'web' is already mapped by container 'api'",
+		"host port 8001/tcp of container 'worker' is already mapped by container 'admin'",
+	}
+	actual := findingMessages(findings)
+	if len(actual) != len(expect) {
+		t.Fatalf("expect %v, but actual is %v", expect, actual)
+	}
+	for i := range expect {
+		if actual[i] != expect[i] {
+			t.Errorf("expect '%s', but actual is '%s'", expect[i], actual[i])
+		}
+	}
+}
+
+func TestLintDeploymentDeadlock(t *testing.T) {
+
+	tests := []struct {
+		min    null.Int
+		max    null.Int
+		expect bool
+	}{
+		{null.IntFrom(100), null.IntFrom(100), true},
+		{null.IntFrom(100), null.IntFrom(200), false},
+		{null.IntFrom(50), null.IntFrom(100), false},
+		{null.Int{}, null.IntFrom(100), false},
+	}
+
+	for _, test := range tests {
+		findings := lintDeploymentDeadlock(types.Service{
+			Name:                  "api",
+			MinimumHealthyPercent: test.min,
+			MaximumPercent:        test.max,
+		})
+		if actual := len(findings) > 0; actual != test.expect {
+			t.Errorf("min %v, max %v: expect finding is %v, but actual is %v", test.min, test.max, test.expect, findingMessages(findings))
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "ecs-formation-lint")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := "rules:\n  privileged: off\n  no-memory: warning\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, lintConfigFile), []byte(config), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	task := "api:\n  # ecs-formation:ignore latest-tag\n  image: nginx\n  privileged: true\n"
+	taskPath := filepath.Join(dir, "task", "api.yml")
+	if err := os.MkdirAll(filepath.Dir(taskPath), 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ioutil.WriteFile(taskPath, []byte(task), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v := &projectValidator{
+		projectDir: dir,
+		errors:     []*types.ValidationError{},
+		taskFiles: map[string]*yamlFile{
+			"api": {Name: "api", Path: taskPath},
+		},
+		tasks: map[string]map[string]types.ContainerDefinition{
+			"api": {
+				"api": {Image: "nginx", Privileged: true},
+			},
+		},
+		clusterFiles: map[string]*yamlFile{
+			"web": {Name: "web", Path: filepath.Join(dir, "service", "web.yml")},
+		},
+		clusters: map[string]map[string]types.Service{
+			"web": {
+				"api": {MinimumHealthyPercent: null.IntFrom(100), MaximumPercent: null.IntFrom(100)},
+			},
+		},
+	}
+
+	v.lint()
+
+	actual := map[string]string{}
+	for _, e := range v.errors {
+		actual[e.Rule] = e.Severity
+	}
+
+	expect := map[string]string{
+		"no-essential":        types.SeverityError,
+		"no-memory":           types.SeverityWarning,
+		"deployment-deadlock": types.SeverityError,
+	}
+	if len(actual) != len(expect) {
+		t.Errorf("expect rules %v, but actual is %v", expect, actual)
+	}
+	for rule, severity := range expect {
+		if actual[rule] != severity {
+			t.Errorf("expect severity of '%s' is '%s', but actual is '%s'", rule, severity, actual[rule])
+		}
+	}
+
+	for _, e := range v.errors {
+		if e.Rule == "no-memory" && (e.File != filepath.Join("task", "api.yml") || e.Line != 1) {
+			t.Errorf("expect no-memory at task/api.yml:1, but actual is %s:%d", e.File, e.Line)
+		}
+	}
+}
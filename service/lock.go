@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/openfresh/ecs-formation/service/types"
+	"github.com/openfresh/ecs-formation/util"
+)
+
+const lockFileHeader = "# Generated by 'ecs-formation task apply --pin-digests'. Do not edit.\n"
+
+// LockFilePath returns path of lock file in project, which is 'ecs-formation.<env>.lock' for overlay.
+func LockFilePath(projectDir string, overlay string) string {
+
+	if overlay == "" {
+		return filepath.Join(projectDir, "ecs-formation.lock")
+	}
+
+	return filepath.Join(projectDir, fmt.Sprintf("ecs-formation.%s.lock", overlay))
+}
+
+// ReadLockFile reads lock file of project. It returns empty lock if the file does not exist.
+func ReadLockFile(projectDir string, overlay string) (*types.LockFile, error) {
+
+	lock := &types.LockFile{
+		Tasks: map[string]*types.TaskLock{},
+	}
+
+	data, err := ioutil.ReadFile(LockFilePath(projectDir, overlay))
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("Failed to read lock file: %v. ", err)
+	}
+	if lock.Tasks == nil {
+		lock.Tasks = map[string]*types.TaskLock{}
+	}
+
+	return lock, nil
+}
+
+func writeLockFile(projectDir string, overlay string, lock *types.LockFile) error {
+
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(LockFilePath(projectDir, overlay), append([]byte(lockFileHeader), data...), 0644)
+}
+
+// compareTaskLock describes differences of plan from the lock. digests are current digests of images
+// by container, which are compared with locked ones if images are not changed.
+func compareTaskLock(locked *types.TaskLock, plan *types.TaskUpdatePlan, params map[string]string, digests map[string]string) []string {
+
+	diffs := []string{}
+
+	names := []string{}
+	for name := range plan.NewContainers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		con := plan.NewContainers[name]
+		pinned, ok := locked.Containers[name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("container '%s' is not locked", name))
+			continue
+		}
+		if pinned.Image != con.Image {
+			diffs = append(diffs, fmt.Sprintf("container '%s': image %s -> %s (locked %s)", name, pinned.Image, con.Image, pinned.Digest))
+		} else if digest, ok := digests[name]; ok && digest != pinned.Digest {
+			diffs = append(diffs, fmt.Sprintf("container '%s': image %s moved %s -> %s", name, con.Image, pinned.Digest, digest))
+		}
+	}
+
+	for _, name := range sortedLockedContainers(locked.Containers) {
+		if _, ok := plan.NewContainers[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("container '%s' is locked, but removed", name))
+		}
+	}
+
+	current := lockParameters(plan.Parameters, params)
+	for _, name := range plan.Parameters {
+		value, ok := locked.Parameters[name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("parameter '%s' is not locked", name))
+		} else if value != current[name] {
+			diffs = append(diffs, fmt.Sprintf("parameter '%s': %s -> %s", name, value, current[name]))
+		}
+	}
+
+	return diffs
+}
+
+// lockParameters returns values of parameters which a task uses. Secret values are masked.
+func lockParameters(names []string, params map[string]string) map[string]string {
+
+	values := map[string]string{}
+	for _, name := range names {
+		values[name] = util.MaskSecrets(params[name])
+	}
+
+	return values
+}
+
+func sortedLockedContainers(m map[string]*types.ContainerLock) []string {
+
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
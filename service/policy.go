@@ -0,0 +1,149 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/openfresh/ecs-formation/logger"
+	"github.com/openfresh/ecs-formation/util"
+)
+
+const (
+	policyDirName = "policy"
+	policyPackage = "ecs_formation"
+	// opaPathEnv overrides path of 'opa' command.
+	opaPathEnv = "ECS_FORMATION_OPA"
+)
+
+// policyInput is the document passed to policies as 'input'.
+type policyInput struct {
+	Kind string      `json:"kind"`
+	Env  string      `json:"env"`
+	Plan interface{} `json:"plan"`
+}
+
+type policyResult struct {
+	Deny []string
+	Warn []string
+}
+
+type opaOutput struct {
+	Result []struct {
+		Expressions []struct {
+			Value map[string]interface{} `json:"value"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// checkPolicies evaluates Rego policies in 'policy' directory of project against plan.
+// 'warn' rules are printed, and any 'deny' rule fails the check. Nothing is checked without the directory.
+func checkPolicies(projectDir string, overlay string, kind string, name string, plan interface{}) error {
+
+	dir := filepath.Join(projectDir, policyDirName)
+	if !isDir(dir) {
+		return nil
+	}
+
+	result, err := evalPolicies(dir, &policyInput{
+		Kind: kind,
+		Env:  overlay,
+		Plan: plan,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range result.Warn {
+		logger.Main.Warn(color.YellowString("Policy warning on %s '%s': %s", kind, name, util.MaskSecrets(msg)))
+	}
+	for _, msg := range result.Deny {
+		logger.Main.Error(color.RedString("Policy denied %s '%s': %s", kind, name, util.MaskSecrets(msg)))
+	}
+
+	if len(result.Deny) > 0 {
+		return fmt.Errorf("%s '%s' is denied by %d policy rule(s). ", kind, name, len(result.Deny))
+	}
+
+	return nil
+}
+
+func evalPolicies(dir string, input *policyInput) (*policyResult, error) {
+
+	opa := os.Getenv(opaPathEnv)
+	if opa == "" {
+		opa = "opa"
+	}
+	if _, err := exec.LookPath(opa); err != nil {
+		return nil, fmt.Errorf("Policy directory '%s' exists, but 'opa' command is not found. Install it or set %s. ", dir, opaPathEnv)
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(opa, "eval", "--format", "json", "--data", dir, "--stdin-input", "data."+policyPackage)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Failed to evaluate policies in '%s': %v: %s", dir, err, bytes.TrimSpace(append(stderr.Bytes(), stdout.Bytes()...)))
+	}
+
+	output := opaOutput{}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("Failed to parse output of opa: %v", err)
+	}
+
+	result := &policyResult{
+		Deny: []string{},
+		Warn: []string{},
+	}
+	for _, r := range output.Result {
+		for _, expr := range r.Expressions {
+			result.Deny = append(result.Deny, policyMessages(expr.Value["deny"])...)
+			result.Warn = append(result.Warn, policyMessages(expr.Value["warn"])...)
+		}
+	}
+	sort.Strings(result.Deny)
+	sort.Strings(result.Warn)
+
+	return result, nil
+}
+
+// policyMessages converts value of rule to messages. Rule may be a set of strings, or objects with 'msg'.
+func policyMessages(value interface{}) []string {
+
+	messages := []string{}
+	switch v := value.(type) {
+	case nil:
+	case []interface{}:
+		for _, item := range v {
+			messages = append(messages, policyMessages(item)...)
+		}
+	case map[string]interface{}:
+		if msg, ok := v["msg"]; ok {
+			messages = append(messages, fmt.Sprint(msg))
+		} else {
+			data, _ := json.Marshal(v)
+			messages = append(messages, string(data))
+		}
+	case string:
+		messages = append(messages, v)
+	case bool:
+		if v {
+			messages = append(messages, "rule matched")
+		}
+	default:
+		messages = append(messages, fmt.Sprint(v))
+	}
+
+	return messages
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openfresh/ecs-formation/util"
+)
+
+// yamlFile is a definition file of task, service or bluegreen merged with parameters and overlay.
+type yamlFile struct {
+	Name        string
+	Path        string
+	OverlayPath string
+	Content     string
+	Parameters  []string
+}
+
+// searchYamlFiles loads '<kind>/*.yml' in project.
+//
+// If project has 'base' directory, files are loaded from 'base/<kind>', and
+// 'overlays/<overlay>/<kind>' files with the same relative path are deep-merged over them.
+func searchYamlFiles(projectDir string, overlay string, kind string, params map[string]string) ([]*yamlFile, error) {
+
+	baseDir := projectBaseDir(projectDir)
+
+	overlayDir := ""
+	if overlay != "" {
+		overlayDir = filepath.Join(projectDir, "overlays", overlay)
+	}
+
+	baseFiles, err := walkYamlFiles(filepath.Join(baseDir, kind))
+	if err != nil {
+		return nil, err
+	}
+
+	overlayFiles := map[string]string{}
+	if overlayDir != "" {
+		overlayFiles, err = walkYamlFiles(filepath.Join(overlayDir, kind))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	paths := map[string]bool{}
+	for rel := range baseFiles {
+		paths[rel] = true
+	}
+	for rel := range overlayFiles {
+		paths[rel] = true
+	}
+
+	rels := []string{}
+	for rel := range paths {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	files := []*yamlFile{}
+	for _, rel := range rels {
+		file, err := loadYamlFile(rel, baseFiles[rel], overlayFiles[rel], params)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// projectBaseDir returns 'base' directory if project has it, or else project directory.
+func projectBaseDir(projectDir string) string {
+
+	baseDir := filepath.Join(projectDir, "base")
+	if !isDir(baseDir) {
+		return projectDir
+	}
+
+	return baseDir
+}
+
+// TaskFilePath returns path of task file for the task definition in base directory of project.
+func TaskFilePath(projectDir string, name string) string {
+	return filepath.Join(projectBaseDir(projectDir), "task", name+".yml")
+}
+
+func loadYamlFile(rel string, basePath string, overlayPath string, params map[string]string) (*yamlFile, error) {
+
+	path := basePath
+	if path == "" {
+		path = overlayPath
+	}
+
+	file := &yamlFile{
+		Name:        strings.TrimSuffix(filepath.Base(rel), ".yml"),
+		Path:        path,
+		OverlayPath: overlayPath,
+	}
+
+	content, used, err := renderYamlFile(path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if basePath != "" && overlayPath != "" {
+		overlayContent, overlayUsed, err := renderYamlFile(overlayPath, params)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err = util.MergeYaml(content, overlayContent)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to merge '%s': %v", basePath, overlayPath, err)
+		}
+		used = unionStrings(used, overlayUsed)
+	}
+
+	file.Content = content
+	file.Parameters = used
+
+	return file, nil
+}
+
+func renderYamlFile(path string, params map[string]string) (string, []string, error) {
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", []string{}, err
+	}
+
+	return util.MergeYamlWithParameters(path, content, params)
+}
+
+// walkYamlFiles returns paths of yml files in dir keyed by relative path.
+func walkYamlFiles(dir string) (map[string]string, error) {
+
+	files := map[string]string{}
+	if !isDir(dir) {
+		return files, nil
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = path
+
+		return nil
+	})
+
+	return files, err
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func unionStrings(a []string, b []string) []string {
+
+	set := map[string]bool{}
+	for _, s := range a {
+		set[s] = true
+	}
+	for _, s := range b {
+		set[s] = true
+	}
+
+	result := []string{}
+	for s := range set {
+		result = append(result, s)
+	}
+	sort.Strings(result)
+
+	return result
+}
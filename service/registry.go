@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/openfresh/ecs-formation/client/ecr"
+	"github.com/openfresh/ecs-formation/service/types"
+)
+
+// manifestMediaTypes are accepted manifests, and lists of them are preferred to get digests of multi-arch images.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+var challengeParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// dockerHubAuthKey is the key of Docker Hub in 'auths' of docker config.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// imageRegistry resolves digests of images with ECR API for ECR images,
+// and Docker Registry HTTP API V2 for others.
+type imageRegistry struct {
+	ecrCli     ecr.Client
+	httpClient *http.Client
+	// digests are resolved digests by image, since tasks often share images.
+	digests map[string]string
+}
+
+func newImageRegistry(ecrCli ecr.Client) *imageRegistry {
+	return &imageRegistry{
+		ecrCli:     ecrCli,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		digests:    map[string]string{},
+	}
+}
+
+// resolveDigest returns digest of image. It returns empty string if the image is not found.
+func (r *imageRegistry) resolveDigest(ref *types.ImageReference) (string, error) {
+
+	key := ref.String()
+	if digest, ok := r.digests[key]; ok {
+		return digest, nil
+	}
+
+	digest, err := r.fetchDigest(ref)
+	if err != nil {
+		return "", err
+	}
+	r.digests[key] = digest
+
+	return digest, nil
+}
+
+func (r *imageRegistry) fetchDigest(ref *types.ImageReference) (string, error) {
+
+	if registryID, region, ok := ref.ECR(); ok {
+		image, err := r.ecrCli.DescribeImage(region, registryID, ref.Repository, ref.Reference())
+		if err != nil {
+			return "", err
+		}
+		if image == nil {
+			return "", nil
+		}
+		return *image.ImageDigest, nil
+	}
+
+	return r.headManifest(ref)
+}
+
+func (r *imageRegistry) headManifest(ref *types.ImageReference) (string, error) {
+
+	scheme := "https"
+	if strings.HasPrefix(ref.Registry, "localhost") || strings.HasPrefix(ref.Registry, "127.0.0.1") {
+		scheme = "http"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, ref.Registry, ref.Repository, ref.Reference())
+
+	resp, err := r.requestManifest("HEAD", manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	authorization := ""
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err = r.authorize(ref, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		resp, err = r.requestManifest("HEAD", manifestURL, authorization)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", nil
+	default:
+		return "", fmt.Errorf("Failed to get manifest of image '%s': %s. ", ref, resp.Status)
+	}
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// some registries return the digest only for GET, so it is calculated from the manifest.
+	resp, err = r.requestManifest("GET", manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	manifest, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
+
+func (r *imageRegistry) requestManifest(method string, manifestURL string, authorization string) (*http.Response, error) {
+
+	req, err := http.NewRequest(method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	return r.httpClient.Do(req)
+}
+
+// authorize returns Authorization header for the challenge of registry. Bearer token is requested
+// with credentials of docker config if they exist, or anonymously.
+func (r *imageRegistry) authorize(ref *types.ImageReference, challenge string) (string, error) {
+
+	username, password, err := r.credentials(ref.Registry)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(strings.ToLower(challenge), "basic") {
+		if username == "" {
+			return "", fmt.Errorf("Registry '%s' requires credentials. Log in with 'docker login %s'. ", ref.Registry, ref.Registry)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	}
+
+	params := map[string]string{}
+	for _, match := range challengeParamPattern.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("Registry '%s' returns unsupported challenge '%s'. ", ref.Registry, challenge)
+	}
+	if params["scope"] == "" {
+		params["scope"] = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+
+	query := url.Values{}
+	query.Set("scope", params["scope"])
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+
+	req, err := http.NewRequest("GET", params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to get token of registry '%s': %s. ", ref.Registry, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	return "Bearer " + token.Token, nil
+}
+
+// credentials returns username and password of registry from credential helpers or 'auths' of docker config,
+// as docker does. They are empty if docker has not logged in to the registry.
+func (r *imageRegistry) credentials(registry string) (string, string, error) {
+
+	config, err := readDockerConfig()
+	if err != nil || config == nil {
+		return "", "", err
+	}
+
+	key := registry
+	if registry == types.DockerHubRegistry {
+		key = dockerHubAuthKey
+	}
+
+	helper := config.CredHelpers[registry]
+	if helper == "" {
+		helper = config.CredsStore
+	}
+	if helper != "" {
+		username, password, err := credentialsFromHelper(helper, key)
+		if err != nil || username != "" {
+			return username, password, err
+		}
+	}
+
+	for _, candidate := range []string{key, "https://" + key, "http://" + key} {
+		auth, ok := config.Auths[candidate]
+		if !ok || auth.Auth == "" {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return "", "", fmt.Errorf("Invalid auth of registry '%s' in docker config: %v. ", registry, err)
+		}
+		tokens := strings.SplitN(string(decoded), ":", 2)
+		if len(tokens) != 2 {
+			return "", "", fmt.Errorf("Invalid auth of registry '%s' in docker config. ", registry)
+		}
+		return tokens[0], tokens[1], nil
+	}
+
+	return "", "", nil
+}
+
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// credentialsFromHelper gets credentials of server from 'docker-credential-<helper>'.
+// They are empty if the helper does not have them.
+func credentialsFromHelper(helper string, server string) (string, string, error) {
+
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(message, "credentials not found") {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("Failed to get credentials of '%s' from docker-credential-%s: %v %s. ", server, helper, err, message)
+	}
+
+	var creds struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return "", "", fmt.Errorf("Failed to read credentials from docker-credential-%s: %v. ", helper, err)
+	}
+
+	return creds.Username, creds.Secret, nil
+}
+
+// readDockerConfig reads config.json in DOCKER_CONFIG or ~/.docker. It returns nil if it does not exist.
+func readDockerConfig() (*dockerConfig, error) {
+
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".docker")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := &dockerConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("Failed to read docker config: %v. ", err)
+	}
+
+	return config, nil
+}
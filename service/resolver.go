@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/openfresh/ecs-formation/client"
+	"github.com/openfresh/ecs-formation/client/s3"
+	"github.com/openfresh/ecs-formation/client/secretsmanager"
+	"github.com/openfresh/ecs-formation/client/ssm"
+	"github.com/openfresh/ecs-formation/util"
+)
+
+// RegisterValueResolvers registers resolvers of AWS resources to refer in YAML.
+func RegisterValueResolvers(awscli client.AWSClient) {
+	util.RegisterValueResolver("ssm", SSMResolver{ssmCli: awscli.SSM})
+	util.RegisterValueResolver("secretsmanager", SecretsManagerResolver{secretsManagerCli: awscli.SecretsManager})
+	util.RegisterValueResolver("s3", S3Resolver{s3Cli: awscli.S3})
+}
+
+// awsResolverSchemes are schemes of RegisterValueResolvers.
+var awsResolverSchemes = []string{"ssm", "secretsmanager", "s3"}
+
+// RegisterOfflineResolvers replaces all resolvers, including '${cmd:...}' and '${file:...}', with OfflineResolver.
+// It is for validation, which neither runs commands nor reads external values.
+func RegisterOfflineResolvers() {
+	for _, scheme := range append(util.ValueResolverSchemes(), awsResolverSchemes...) {
+		util.RegisterValueResolver(scheme, OfflineResolver{scheme: scheme})
+	}
+}
+
+// OfflineResolver keeps '${scheme:reference}' as it is.
+type OfflineResolver struct {
+	scheme string
+}
+
+func (r OfflineResolver) Resolve(reference string, dir string) (string, bool, error) {
+	return fmt.Sprintf("${%s:%s}", r.scheme, reference), false, nil
+}
+
+// SSMResolver resolves '${ssm:/parameter/name}'. SecureString parameter is secret.
+type SSMResolver struct {
+	ssmCli ssm.Client
+}
+
+func (r SSMResolver) Resolve(reference string, dir string) (string, bool, error) {
+
+	param, err := r.ssmCli.GetParameter(reference, true)
+	if err != nil {
+		return "", false, err
+	}
+
+	return *param.Value, *param.Type == "SecureString", nil
+}
+
+// SecretsManagerResolver resolves '${secretsmanager:secret-id}' or '${secretsmanager:secret-id#json_key}'.
+type SecretsManagerResolver struct {
+	secretsManagerCli secretsmanager.Client
+}
+
+func (r SecretsManagerResolver) Resolve(reference string, dir string) (string, bool, error) {
+
+	secretID := reference
+	jsonKey := ""
+	if i := strings.LastIndex(reference, "#"); i >= 0 {
+		secretID = reference[:i]
+		jsonKey = reference[i+1:]
+	}
+
+	result, err := r.secretsManagerCli.GetSecretValue(secretID)
+	if err != nil {
+		return "", true, err
+	}
+
+	if result.SecretString == nil {
+		return "", true, fmt.Errorf("secret '%s' does not have string value", secretID)
+	}
+
+	if jsonKey == "" {
+		return *result.SecretString, true, nil
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(*result.SecretString), &values); err != nil {
+		return "", true, fmt.Errorf("secret '%s' is not JSON: %v", secretID, err)
+	}
+
+	value, ok := values[jsonKey]
+	if !ok {
+		return "", true, fmt.Errorf("secret '%s' does not have key '%s'", secretID, jsonKey)
+	}
+
+	return fmt.Sprint(value), true, nil
+}
+
+// S3Resolver resolves '${s3://bucket/key}' by content of the object.
+type S3Resolver struct {
+	s3Cli s3.Client
+}
+
+func (r S3Resolver) Resolve(reference string, dir string) (string, bool, error) {
+
+	tokens := strings.SplitN(strings.TrimPrefix(reference, "//"), "/", 2)
+	if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+		return "", false, fmt.Errorf("'s3:%s' should be 's3://bucket/key'", reference)
+	}
+
+	result, err := r.s3Cli.GetObject(tokens[0], tokens[1])
+	if err != nil {
+		return "", false, err
+	}
+	defer result.Body.Close()
+
+	content, err := ioutil.ReadAll(result.Body)
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), false, nil
+}
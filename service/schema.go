@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/openfresh/ecs-formation/service/types"
+	"github.com/openfresh/ecs-formation/util"
+)
+
+// SchemaKinds are kinds of files which JSON Schema is generated for.
+var SchemaKinds = []string{"task", "service", "bluegreen"}
+
+var extendsSchema = map[string]interface{}{
+	"description": "Entry to inherit, as 'name' in the same file or {file, name}.",
+	"oneOf": []interface{}{
+		map[string]interface{}{"type": "string"},
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "File relative to the current file or '_shared' directory.",
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the entry in the file.",
+				},
+			},
+			"required":             []interface{}{"name"},
+			"additionalProperties": false,
+		},
+	},
+}
+
+// GenerateSchema generates JSON Schema of task, service or bluegreen files from their types,
+// so that it follows the definitions without maintaining it by hand.
+func GenerateSchema(kind string) (map[string]interface{}, error) {
+
+	var entry map[string]interface{}
+	var title string
+	properties := map[string]interface{}{}
+	switch kind {
+	case "task":
+		entry = util.JSONSchema(reflect.TypeOf(types.ContainerDefinition{}))
+		entry["properties"].(map[string]interface{})["extends"] = extendsSchema
+		volumes := util.JSONSchema(reflect.TypeOf(map[string]types.TaskVolume{}))
+		volumes["description"] = "Task-level volumes by name, which containers mount as 'name:containerPath'."
+		properties["volumes"] = volumes
+		title = "ecs-formation task definition: containers by name"
+	case "service":
+		entry = util.JSONSchema(reflect.TypeOf(types.Service{}))
+		entry["properties"].(map[string]interface{})["extends"] = extendsSchema
+		title = "ecs-formation cluster: services by name"
+	case "bluegreen":
+		entry = util.JSONSchema(reflect.TypeOf(types.BlueGreen{}))
+		title = "ecs-formation bluegreen: groups by name"
+	default:
+		return nil, fmt.Errorf("Unknown schema kind '%s'. It should be task, service or bluegreen. ", kind)
+	}
+
+	return map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                title,
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": entry,
+	}, nil
+}
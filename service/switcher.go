@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/fatih/color"
 	"github.com/openfresh/ecs-formation/client"
 	"github.com/openfresh/ecs-formation/logger"
@@ -17,7 +18,11 @@ type ELBSwitcher interface {
 }
 
 func NewELBSwitcher(awscli client.AWSClient, bgplan *types.BlueGreenPlan) ELBSwitcher {
-	if bgplan.ElbV2 != nil && len(bgplan.ElbV2.TargetGroups) > 0 {
+	if bgplan.IsServiceMode() {
+		return &ServiceSwitcher{
+			awsCli: client.AWSCli,
+		}
+	} else if len(bgplan.TargetGroups) > 0 {
 		return &ELBV2Switcher{
 			awsCli: client.AWSCli,
 		}
@@ -36,32 +41,27 @@ type ELBV2Switcher struct {
 	awsCli client.AWSClient
 }
 
+type ServiceSwitcher struct {
+	awsCli client.AWSClient
+}
+
+const (
+	healthCheckInterval = 5 * time.Second
+	healthCheckRetry    = 60
+	// services which are not stable in stableServicesInterval * stableServicesRetry are failed, so that they are rolled back.
+	stableServicesInterval = 10 * time.Second
+	stableServicesRetry    = 90
+)
+
 func (s ELBV1Switcher) Apply(clusterService ClusterService, bgplan *types.BlueGreenPlan, nodeploy bool) error {
 
-	var targetGreen bool
-	for _, lb := range bgplan.Blue.AutoScalingGroup.LoadBalancerNames {
-		if *lb == bgplan.PrimaryElb {
-			targetGreen = true
-		}
+	current, next, currentLabel, nextLabel, err := selectServiceSets(bgplan)
+	if err != nil {
+		return err
 	}
 
-	var currentLabel string
-	var nextLabel string
-	var current *types.ServiceSet
-	var next *types.ServiceSet
 	primaryLb := bgplan.PrimaryElb
 	standbyLb := bgplan.StandbyElb
-	if targetGreen {
-		current = bgplan.Blue
-		next = bgplan.Green
-		currentLabel = color.CyanString("blue")
-		nextLabel = color.GreenString("green")
-	} else {
-		current = bgplan.Green
-		next = bgplan.Blue
-		currentLabel = color.GreenString("green")
-		nextLabel = color.CyanString("blue")
-	}
 
 	primaryGroup := []string{primaryLb}
 	standbyGroup := []string{standbyLb}
@@ -72,12 +72,19 @@ func (s ELBV1Switcher) Apply(clusterService ClusterService, bgplan *types.BlueGr
 
 	logger.Main.Infof("Current status is '%s'", currentLabel)
 	logger.Main.Infof("Start Blue-Green Deployment: %s to %s ...", currentLabel, nextLabel)
+
+	capacity := NewCapacityController(s.awsCli)
+	if err := capacity.ScaleUp(current, next, nextLabel); err != nil {
+		restoreCapacity(capacity, next, nextLabel)
+		return err
+	}
+
 	if nodeploy {
 		logger.Main.Infof("Without deployment. It only replaces load balancers.")
 	} else {
 		// deploy service
-		logger.Main.Infof("Updating %s@%s service at %s ...", next.NewService.Service, next.NewService.Cluster, nextLabel)
-		if err := clusterService.ApplyServicePlan(next.ClusterUpdatePlan); err != nil {
+		if err := deployServices(s.awsCli, clusterService, next, nextLabel); err != nil {
+			restoreCapacity(capacity, next, nextLabel)
 			return err
 		}
 	}
@@ -120,7 +127,14 @@ func (s ELBV1Switcher) Apply(clusterService ClusterService, bgplan *types.BlueGr
 		logger.Main.Infof("Attached %s group to %s(standby).", currentLabel, e)
 	}
 
-	return nil
+	return capacity.ScaleDown(current, currentLabel)
+}
+
+// restoreCapacity scales next group back after failed deployment, so that standby group does not stay at full size.
+func restoreCapacity(capacity *CapacityController, next *types.ServiceSet, label string) {
+	if err := capacity.Restore(next, label); err != nil {
+		logger.Main.Errorf("Scaling back is failed: %s", err.Error())
+	}
 }
 
 func (s ELBV1Switcher) waitLoadBalancer(group string, lb string) error {
@@ -154,74 +168,37 @@ func (s ELBV1Switcher) waitLoadBalancer(group string, lb string) error {
 
 func (s ELBV2Switcher) Apply(clusterService ClusterService, bgplan *types.BlueGreenPlan, nodeploy bool) error {
 
-	var targetGreen bool
-	for _, tg := range bgplan.Blue.AutoScalingGroup.TargetGroupARNs {
-		topTg := bgplan.ElbV2.TargetGroups[0]
-		// TODO name check
-		if strings.Contains(*tg, topTg.PrimaryGroup) {
-			targetGreen = true
-		}
-	}
-
-	var currentLabel string
-	var nextLabel string
-	var current *types.ServiceSet
-	var next *types.ServiceSet
-
-	topGroup := bgplan.ElbV2.TargetGroups[0]
-
-	primaryTg := topGroup.PrimaryGroup
-	if targetGreen {
-		current = bgplan.Blue
-		next = bgplan.Green
-		currentLabel = color.CyanString("blue")
-		nextLabel = color.GreenString("green")
-	} else {
-		current = bgplan.Green
-		next = bgplan.Blue
-		currentLabel = color.GreenString("green")
-		nextLabel = color.CyanString("blue")
-	}
-
-	allGroup := []string{}
-	primaryGroup := []string{}
-	standbyGroup := []string{}
-	for _, tg := range bgplan.ElbV2.TargetGroups {
-		primaryGroup = append(primaryGroup, tg.PrimaryGroup)
-		standbyGroup = append(standbyGroup, tg.StandbyGroup)
-		allGroup = append(allGroup, tg.PrimaryGroup)
-		allGroup = append(allGroup, tg.StandbyGroup)
-	}
-
-	tgmap, err := s.awsCli.ELBV2.DescribeTargetGroup(allGroup)
+	current, next, currentLabel, nextLabel, err := selectServiceSets(bgplan)
 	if err != nil {
 		return err
 	}
 
+	primaryGroup := []string{}
+	standbyGroup := []string{}
 	primaryGroupARNs := []string{}
 	standbyGroupARNs := []string{}
-	for name, tg := range tgmap {
-		for _, pg := range primaryGroup {
-			if pg == name {
-				primaryGroupARNs = append(primaryGroupARNs, *tg.TargetGroupArn)
-			}
-		}
-
-		for _, sg := range standbyGroup {
-			if sg == name {
-				standbyGroupARNs = append(standbyGroupARNs, *tg.TargetGroupArn)
-			}
-		}
+	for _, tg := range bgplan.TargetGroups {
+		primaryGroup = append(primaryGroup, tg.PrimaryName)
+		standbyGroup = append(standbyGroup, tg.StandbyName)
+		primaryGroupARNs = append(primaryGroupARNs, tg.PrimaryARN)
+		standbyGroupARNs = append(standbyGroupARNs, tg.StandbyARN)
 	}
 
 	logger.Main.Infof("Current status is '%s'", currentLabel)
 	logger.Main.Infof("Start Blue-Green Deployment: %s to %s ...", currentLabel, nextLabel)
+
+	capacity := NewCapacityController(s.awsCli)
+	if err := capacity.ScaleUp(current, next, nextLabel); err != nil {
+		restoreCapacity(capacity, next, nextLabel)
+		return err
+	}
+
 	if nodeploy {
 		logger.Main.Infof("Without deployment. It only replaces load balancers.")
 	} else {
 		// deploy service
-		logger.Main.Infof("Updating %s@%s service at %s ...", next.NewService.Service, next.NewService.Cluster, nextLabel)
-		if err := clusterService.ApplyServicePlan(next.ClusterUpdatePlan); err != nil {
+		if err := deployServices(s.awsCli, clusterService, next, nextLabel); err != nil {
+			restoreCapacity(capacity, next, nextLabel)
 			return err
 		}
 	}
@@ -234,7 +211,7 @@ func (s ELBV2Switcher) Apply(clusterService ClusterService, bgplan *types.BlueGr
 		logger.Main.Infof("Attached to attach %s group to %s(primary).", nextLabel, e)
 	}
 
-	if err := s.waitTargetGroup(*next.AutoScalingGroup.AutoScalingGroupName, primaryTg); err != nil {
+	if err := s.waitTargetGroups(*next.AutoScalingGroup.AutoScalingGroupName, bgplan.TargetGroups); err != nil {
 		return err
 	}
 	logger.Main.Infof("Added %s group to primary", nextLabel)
@@ -265,10 +242,10 @@ func (s ELBV2Switcher) Apply(clusterService ClusterService, bgplan *types.BlueGr
 		logger.Main.Infof("Attached %s group to %s(standby).", currentLabel, e)
 	}
 
-	return nil
+	return capacity.ScaleDown(current, currentLabel)
 }
 
-func (s ELBV2Switcher) waitTargetGroup(group string, tg string) error {
+func (s ELBV2Switcher) waitTargetGroups(group string, pairs []*types.TargetGroupPair) error {
 	for {
 		time.Sleep(5 * time.Second)
 		targetGroups, err := s.awsCli.Autoscaling.DescribeLoadBalancerTargetGroups(group)
@@ -285,20 +262,268 @@ func (s ELBV2Switcher) waitTargetGroup(group string, tg string) error {
 		// for in-flight requests to complete before deregistering the instances.
 		//    Removed - All Auto Scaling instances are deregistered from the target group.
 
-		if len(targetGroups) == 0 {
-			return fmt.Errorf("cannot get target group %s at %s", tg, group)
+		states := map[string]string{}
+		for _, targetGroup := range targetGroups {
+			states[*targetGroup.LoadBalancerTargetGroupARN] = *targetGroup.State
 		}
 
-		for _, targetGroup := range targetGroups {
-			if strings.Contains(*targetGroup.LoadBalancerTargetGroupARN, tg) {
-				state := *targetGroup.State
-
-				if state == "Added" || state == "InService" {
-					logger.Main.Infof("%s: TargetGroup %s to %s", color.GreenString(state), tg, group)
-					return nil
-				}
-				logger.Main.Infof("%s: TargetGroup %s to %s", color.YellowString(state), tg, group)
+		ready := 0
+		for _, pair := range pairs {
+			state, ok := states[pair.PrimaryARN]
+			if !ok {
+				return fmt.Errorf("cannot get target group %s at %s", pair.PrimaryName, group)
+			}
+
+			if state == "Added" || state == "InService" {
+				logger.Main.Infof("%s: TargetGroup %s to %s", color.GreenString(state), pair.PrimaryName, group)
+				ready++
+			} else {
+				logger.Main.Infof("%s: TargetGroup %s to %s", color.YellowString(state), pair.PrimaryName, group)
+			}
+		}
+
+		if ready == len(pairs) {
+			return nil
+		}
+	}
+}
+
+func (s ServiceSwitcher) Apply(clusterService ClusterService, bgplan *types.BlueGreenPlan, nodeploy bool) error {
+
+	current, next, currentLabel, nextLabel, err := selectServiceSets(bgplan)
+	if err != nil {
+		return err
+	}
+
+	logger.Main.Infof("Current status is '%s'", currentLabel)
+	logger.Main.Infof("Start Blue-Green Deployment: %s to %s ...", currentLabel, nextLabel)
+
+	if nodeploy {
+		logger.Main.Infof("Without deployment. It only replaces listener rules.")
+	} else {
+		// deploy service
+		if err := deployServices(s.awsCli, clusterService, next, nextLabel); err != nil {
+			return err
+		}
+	}
+
+	// switch listener rules after all targets of next become healthy
+	if err := s.waitHealthyTargets(next.TargetGroupARN, nextLabel); err != nil {
+		return err
+	}
+
+	switched := []*elbv2.Rule{}
+	for _, rule := range bgplan.ListenerRules {
+		if err := s.forward(rule, next.TargetGroupARN); err != nil {
+			if rerr := s.rollback(switched, current.TargetGroupARN, currentLabel); rerr != nil {
+				logger.Main.Errorf("Rollback is failed: %s", rerr.Error())
 			}
+			return err
+		}
+		switched = append(switched, rule)
+		logger.Main.Infof("Switched listener rule %s to %s.", *rule.RuleArn, nextLabel)
+	}
+
+	time.Sleep(2 * healthCheckInterval)
+
+	if err := s.checkHealthyTargets(next.TargetGroupARN); err != nil {
+		logger.Main.Errorf("%s group became unhealthy after switching: %s", nextLabel, err.Error())
+		if rerr := s.rollback(switched, current.TargetGroupARN, currentLabel); rerr != nil {
+			logger.Main.Errorf("Rollback is failed: %s", rerr.Error())
 		}
+		return err
 	}
+
+	return nil
+}
+
+// forward modifies the rule to forward to target group.
+func (s ServiceSwitcher) forward(rule *elbv2.Rule, targetGroupARN string) error {
+
+	actions := []*elbv2.Action{}
+	for _, action := range rule.Actions {
+		if *action.Type != elbv2.ActionTypeEnumForward {
+			actions = append(actions, action)
+			continue
+		}
+
+		// order and other settings of the action are kept, so that rules with multiple actions
+		// like authenticate-oidc and forward are accepted.
+		forward := *action
+		forward.TargetGroupArn = aws.String(targetGroupARN)
+		if action.ForwardConfig != nil {
+			forward.ForwardConfig = &elbv2.ForwardActionConfig{
+				TargetGroups: []*elbv2.TargetGroupTuple{
+					{
+						TargetGroupArn: aws.String(targetGroupARN),
+						Weight:         aws.Int64(1),
+					},
+				},
+				TargetGroupStickinessConfig: action.ForwardConfig.TargetGroupStickinessConfig,
+			}
+		}
+		actions = append(actions, &forward)
+	}
+
+	_, err := s.awsCli.ELBV2.ModifyRule(&elbv2.ModifyRuleInput{
+		RuleArn: rule.RuleArn,
+		Actions: actions,
+	})
+
+	return err
+}
+
+func (s ServiceSwitcher) rollback(rules []*elbv2.Rule, targetGroupARN string, label string) error {
+
+	for _, rule := range rules {
+		if err := s.forward(rule, targetGroupARN); err != nil {
+			return err
+		}
+		logger.Main.Warnf("Rolled back listener rule %s to %s.", *rule.RuleArn, label)
+	}
+
+	return nil
+}
+
+func (s ServiceSwitcher) waitHealthyTargets(targetGroupARN string, label string) error {
+
+	for i := 0; i < healthCheckRetry; i++ {
+		descs, err := s.awsCli.ELBV2.DescribeTargetHealth(targetGroupARN)
+		if err != nil {
+			return err
+		}
+
+		healthy := 0
+		for _, desc := range descs {
+			if *desc.TargetHealth.State == elbv2.TargetHealthStateEnumHealthy {
+				healthy++
+			}
+		}
+
+		if len(descs) > 0 && healthy == len(descs) {
+			logger.Main.Infof("%s: %d/%d targets of %s group", color.GreenString("healthy"), healthy, len(descs), label)
+			return nil
+		}
+		logger.Main.Infof("%s: %d/%d targets of %s group", color.YellowString("waiting"), healthy, len(descs), label)
+
+		time.Sleep(healthCheckInterval)
+	}
+
+	return fmt.Errorf("targets of %s group did not become healthy", label)
+}
+
+func (s ServiceSwitcher) checkHealthyTargets(targetGroupARN string) error {
+
+	descs, err := s.awsCli.ELBV2.DescribeTargetHealth(targetGroupARN)
+	if err != nil {
+		return err
+	}
+
+	if len(descs) == 0 {
+		return fmt.Errorf("no targets are registered with %s", targetGroupARN)
+	}
+
+	for _, desc := range descs {
+		if *desc.TargetHealth.State != elbv2.TargetHealthStateEnumHealthy {
+			return fmt.Errorf("target %s is %s", *desc.Target.Id, *desc.TargetHealth.State)
+		}
+	}
+
+	return nil
+}
+
+// selectServiceSets returns current (active) and next service sets with labels.
+func selectServiceSets(bgplan *types.BlueGreenPlan) (*types.ServiceSet, *types.ServiceSet, string, string, error) {
+
+	active, err := bgplan.ActiveColor()
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	if active == types.ColorBlue {
+		return bgplan.Blue, bgplan.Green, color.CyanString("blue"), color.GreenString("green"), nil
+	}
+
+	return bgplan.Green, bgplan.Blue, color.GreenString("green"), color.CyanString("blue"), nil
+}
+
+// refreshClusterUpdatePlan creates update plan of the cluster again,
+// if it could not be created because no instance had joined the cluster at planning.
+func refreshClusterUpdatePlan(clusterService ClusterService, set *types.ServiceSet) error {
+
+	if set.ClusterUpdatePlan != nil {
+		return nil
+	}
+
+	plans, err := clusterService.CreateServiceUpdatePlans()
+	if err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		if plan.Name == set.NewService.Cluster {
+			filtered, err := filterServiceUpdatePlan(plan, set.NewService.ServiceNames())
+			if err != nil {
+				return err
+			}
+			set.ClusterUpdatePlan = filtered
+			return nil
+		}
+	}
+
+	return fmt.Errorf("ECS Cluster '%s' is not found. ", set.NewService.Cluster)
+}
+
+// deployServices applies services of the color, and waits until all of them become stable.
+func deployServices(awscli client.AWSClient, clusterService ClusterService, set *types.ServiceSet, label string) error {
+
+	cluster := set.NewService.Cluster
+	services := set.NewService.ServiceNames()
+
+	for _, name := range services {
+		logger.Main.Infof("Updating %s@%s service at %s ...", name, cluster, label)
+	}
+
+	if err := refreshClusterUpdatePlan(clusterService, set); err != nil {
+		return err
+	}
+
+	if err := clusterService.ApplyServicePlan(set.ClusterUpdatePlan); err != nil {
+		return err
+	}
+
+	return waitStableServices(awscli, cluster, services)
+}
+
+func waitStableServices(awscli client.AWSClient, cluster string, services []string) error {
+
+	deploying := []string{}
+	for i := 0; i < stableServicesRetry; i++ {
+		result, err := awscli.ECS.DescribeService(cluster, aws.StringSlice(services))
+		if err != nil {
+			return err
+		}
+
+		if len(result.Failures) > 0 {
+			return fmt.Errorf("Service '%s' is not found. ", *result.Failures[0].Arn)
+		}
+
+		deploying = []string{}
+		for _, srv := range result.Services {
+			if len(srv.Deployments) == 1 && *srv.RunningCount == *srv.DesiredCount {
+				logger.Main.Infof("%s: service '%s@%s' running %d/%d", color.GreenString("Stable"), *srv.ServiceName, cluster, *srv.RunningCount, *srv.DesiredCount)
+			} else {
+				logger.Main.Infof("%s: service '%s@%s' running %d/%d", color.YellowString("Deploying"), *srv.ServiceName, cluster, *srv.RunningCount, *srv.DesiredCount)
+				deploying = append(deploying, *srv.ServiceName)
+			}
+		}
+
+		if len(deploying) == 0 {
+			return nil
+		}
+
+		time.Sleep(stableServicesInterval)
+	}
+
+	return fmt.Errorf("Services '%s' on '%s' did not become stable in %v. ", strings.Join(deploying, ", "), cluster, stableServicesInterval*stableServicesRetry)
 }
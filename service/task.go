@@ -1,50 +1,59 @@
 package service
 
 import (
-	"io/ioutil"
-	"os"
+	"fmt"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	awsecs "github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/fatih/color"
 	"github.com/openfresh/ecs-formation/client"
+	"github.com/openfresh/ecs-formation/client/cloudwatchlogs"
 	"github.com/openfresh/ecs-formation/client/ecs"
-	"github.com/openfresh/ecs-formation/client/s3"
 	"github.com/openfresh/ecs-formation/logger"
 	"github.com/openfresh/ecs-formation/service/types"
-	"github.com/openfresh/ecs-formation/util"
 )
 
 type TaskService interface {
 	SearchTaskDefinitions() (map[string]*types.TaskDefinition, error)
-	CreateTaskPlans() []*types.TaskUpdatePlan
-	CreateTaskUpdatePlans(tasks map[string]*types.TaskDefinition) []*types.TaskUpdatePlan
-	CreateTaskUpdatePlan(task *types.TaskDefinition) *types.TaskUpdatePlan
+	CreateTaskPlans() ([]*types.TaskUpdatePlan, error)
+	CreateTaskUpdatePlans(tasks map[string]*types.TaskDefinition) ([]*types.TaskUpdatePlan, error)
+	CreateTaskUpdatePlan(task *types.TaskDefinition) (*types.TaskUpdatePlan, error)
 	GetTaskDefinitions() map[string]*types.TaskDefinition
 	ApplyTaskDefinitionPlans(plans []*types.TaskUpdatePlan) ([]*awsecs.TaskDefinition, error)
 	ApplyTaskDefinitionPlan(task *types.TaskUpdatePlan) (*awsecs.TaskDefinition, error)
 	GetCurrentRevision(td string) (int64, error)
+	CheckImages(plans []*types.TaskUpdatePlan)
+	PinImageDigests(plans []*types.TaskUpdatePlan) error
+	CompareLock(plan *types.TaskUpdatePlan) ([]string, error)
+	WriteLock(plans []*types.TaskUpdatePlan, results []*awsecs.TaskDefinition) error
+	GetRevisionHistory(family string, limit int) ([]*types.TaskRevision, error)
+	DiffRevisions(family string, from int64, to int64) ([]string, error)
+	PruneRevisions(families []string, keep int, dryRun bool) ([]*types.TaskRevision, error)
 }
 
 type ConcreteTaskService struct {
 	ecsCli     ecs.Client
-	s3Cli      s3.Client
+	logsCli    cloudwatchlogs.Client
 	projectDir string
+	overlay    string
 	target     string
 	params     map[string]string
 	taskDefs   map[string]*types.TaskDefinition
+	// registry is shared by checks, pinning and lock comparison, so that images are resolved once.
+	registry *imageRegistry
 }
 
-func NewTaskService(projectDir string, target string, params map[string]string) (TaskService, error) {
+func NewTaskService(projectDir string, overlay string, target string, params map[string]string) (TaskService, error) {
 	service := ConcreteTaskService{
 		ecsCli:     client.AWSCli.ECS,
-		s3Cli:      client.AWSCli.S3,
+		logsCli:    client.AWSCli.CloudWatchLogs,
 		projectDir: projectDir,
+		overlay:    overlay,
 		target:     target,
 		params:     params,
+		registry:   newImageRegistry(client.AWSCli.ECR),
 	}
 
 	defs, err := service.SearchTaskDefinitions()
@@ -59,74 +68,100 @@ func NewTaskService(projectDir string, target string, params map[string]string)
 
 func (s ConcreteTaskService) SearchTaskDefinitions() (map[string]*types.TaskDefinition, error) {
 
-	taskDir := s.projectDir + "/task"
 	taskDefMap := map[string]*types.TaskDefinition{}
-	filePattern := regexp.MustCompile(`^.+\/(.+)\.yml$`)
 
-	err := filepath.Walk(taskDir, func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() || !strings.HasSuffix(path, ".yml") {
-			return nil
-		}
+	files, err := searchYamlFiles(s.projectDir, s.overlay, "task", s.params)
+	if err != nil {
+		return taskDefMap, err
+	}
 
-		content, err := ioutil.ReadFile(path)
-		if err != nil {
-			return err
+	for _, file := range files {
+		if err := resolveExtends(s.projectDir, file, s.params); err != nil {
+			return taskDefMap, err
 		}
 
-		merged := util.MergeYamlWithParameters(content, s.params)
-		tokens := filePattern.FindStringSubmatch(path)
-		taskDefName := tokens[1]
-
-		taskDefinition, err := types.CreateTaskDefinition(taskDefName, merged, filepath.Dir(path), s.s3Cli)
+		taskDefinition, err := types.CreateTaskDefinition(file.Name, file.Content, filepath.Dir(file.Path))
 		if err != nil {
-			return err
+			return taskDefMap, err
 		}
+		taskDefinition.Parameters = file.Parameters
 
-		taskDefMap[taskDefName] = taskDefinition
-
-		return nil
-	})
-
-	if err != nil {
-		return taskDefMap, err
+		taskDefMap[file.Name] = taskDefinition
 	}
 
 	return taskDefMap, nil
 }
 
-func (s ConcreteTaskService) CreateTaskPlans() []*types.TaskUpdatePlan {
+func (s ConcreteTaskService) CreateTaskPlans() ([]*types.TaskUpdatePlan, error) {
 
-	plans := s.CreateTaskUpdatePlans(s.taskDefs)
+	plans, err := s.CreateTaskUpdatePlans(s.taskDefs)
+	if err != nil {
+		return plans, err
+	}
 
 	for _, plan := range plans {
 		logger.Main.Infof("Task Definition '%v'", plan.Name)
 	}
 
-	return plans
+	return plans, nil
 }
 
-func (s ConcreteTaskService) CreateTaskUpdatePlans(tasks map[string]*types.TaskDefinition) []*types.TaskUpdatePlan {
+func (s ConcreteTaskService) CreateTaskUpdatePlans(tasks map[string]*types.TaskDefinition) ([]*types.TaskUpdatePlan, error) {
 	plans := []*types.TaskUpdatePlan{}
 	for _, task := range tasks {
 		if len(s.target) == 0 || s.target == task.Name {
-			plans = append(plans, s.CreateTaskUpdatePlan(task))
+			plan, err := s.CreateTaskUpdatePlan(task)
+			if err != nil {
+				return []*types.TaskUpdatePlan{}, err
+			}
+			plans = append(plans, plan)
 		}
 	}
 
-	return plans
+	return plans, nil
 }
 
-func (s ConcreteTaskService) CreateTaskUpdatePlan(task *types.TaskDefinition) *types.TaskUpdatePlan {
+func (s ConcreteTaskService) CreateTaskUpdatePlan(task *types.TaskDefinition) (*types.TaskUpdatePlan, error) {
 	newContainers := map[string]*types.ContainerDefinition{}
 
 	for _, con := range task.ContainerDefinitions {
 		newContainers[con.Name] = con
 	}
 
+	newLogGroups, err := s.searchNewLogGroups(newContainers)
+	if err != nil {
+		return nil, err
+	}
+
 	return &types.TaskUpdatePlan{
 		Name:          task.Name,
 		NewContainers: newContainers,
+		Volumes:       task.Volumes,
+		Parameters:    task.Parameters,
+		NewLogGroups:  newLogGroups,
+	}, nil
+}
+
+// searchNewLogGroups returns log groups of awslogs containers which do not exist yet.
+func (s ConcreteTaskService) searchNewLogGroups(containers map[string]*types.ContainerDefinition) ([]*types.LogGroup, error) {
+
+	groups, err := types.ToLogGroups(containers)
+	if err != nil {
+		return []*types.LogGroup{}, err
 	}
+
+	newGroups := []*types.LogGroup{}
+	for _, group := range groups {
+		current, err := s.logsCli.DescribeLogGroup(group.Region, group.Name)
+		if err != nil {
+			return []*types.LogGroup{}, err
+		}
+		if current == nil {
+			newGroups = append(newGroups, group)
+		}
+	}
+
+	return newGroups, nil
 }
 
 func (s ConcreteTaskService) GetTaskDefinitions() map[string]*types.TaskDefinition {
@@ -135,12 +170,31 @@ func (s ConcreteTaskService) GetTaskDefinitions() map[string]*types.TaskDefiniti
 
 func (s ConcreteTaskService) ApplyTaskDefinitionPlans(plans []*types.TaskUpdatePlan) ([]*awsecs.TaskDefinition, error) {
 
+	for _, plan := range plans {
+		if err := checkPolicies(s.projectDir, s.overlay, "task", plan.Name, plan); err != nil {
+			return []*awsecs.TaskDefinition{}, err
+		}
+	}
+
 	logger.Main.Info("Start apply Task definitions...")
 
+	// task definitions can share a new log group, which is created once before registering any of them.
+	groups := []*types.LogGroup{}
+	for _, plan := range plans {
+		groups = append(groups, plan.NewLogGroups...)
+	}
+	groups, err := types.MergeLogGroups(groups)
+	if err != nil {
+		return []*awsecs.TaskDefinition{}, err
+	}
+	if err := s.createLogGroups(groups); err != nil {
+		return []*awsecs.TaskDefinition{}, err
+	}
+
 	outputs := []*awsecs.TaskDefinition{}
 	for _, plan := range plans {
 
-		result, err := s.ApplyTaskDefinitionPlan(plan)
+		result, err := s.registerTaskDefinition(plan)
 
 		if err != nil {
 			logger.Main.Errorf("Register Task Definition '%s' is error.", plan.Name)
@@ -156,16 +210,40 @@ func (s ConcreteTaskService) ApplyTaskDefinitionPlans(plans []*types.TaskUpdateP
 
 func (s ConcreteTaskService) ApplyTaskDefinitionPlan(task *types.TaskUpdatePlan) (*awsecs.TaskDefinition, error) {
 
+	if err := s.createLogGroups(task.NewLogGroups); err != nil {
+		return nil, err
+	}
+
+	return s.registerTaskDefinition(task)
+}
+
+func (s ConcreteTaskService) createLogGroups(groups []*types.LogGroup) error {
+
+	for _, group := range groups {
+		if err := s.logsCli.CreateLogGroup(group.Region, group.Name, group.RetentionInDays); err != nil {
+			return err
+		}
+		logger.Main.Infof("Created log group '%s'.", color.CyanString(group.Name))
+	}
+
+	return nil
+}
+
+func (s ConcreteTaskService) registerTaskDefinition(task *types.TaskUpdatePlan) (*awsecs.TaskDefinition, error) {
+
 	containers := []*types.ContainerDefinition{}
 	for _, con := range task.NewContainers {
 		containers = append(containers, con)
 	}
 
 	conDefs := []*awsecs.ContainerDefinition{}
-	volumes := []*awsecs.Volume{}
+	volumes, err := types.ToVolumes(task.Volumes)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, con := range containers {
-		conDef, volumeItems, err := types.CreateContainerDefinition(con)
+		conDef, volumeItems, err := types.CreateContainerDefinition(con, task.Volumes)
 		if err != nil {
 			return nil, err
 		}
@@ -176,6 +254,11 @@ func (s ConcreteTaskService) ApplyTaskDefinitionPlan(task *types.TaskUpdatePlan)
 		}
 	}
 
+	volumes, err = types.MergeVolumes(volumes)
+	if err != nil {
+		return nil, err
+	}
+
 	return s.ecsCli.RegisterTaskDefinition(task.Name, conDefs, volumes)
 }
 
@@ -188,3 +271,242 @@ func (s ConcreteTaskService) GetCurrentRevision(td string) (int64, error) {
 
 	return *result.Revision, nil
 }
+
+// CheckImages confirms images of containers exist in registries, and sets problems to ImageErrors of plans.
+func (s ConcreteTaskService) CheckImages(plans []*types.TaskUpdatePlan) {
+
+	for _, plan := range plans {
+		plan.ImageErrors = map[string]string{}
+		for name, con := range plan.NewContainers {
+			ref, err := types.ParseImageReference(con.Image)
+			if err != nil {
+				plan.ImageErrors[name] = err.Error()
+				continue
+			}
+
+			digest, err := s.registry.resolveDigest(ref)
+			if err != nil {
+				plan.ImageErrors[name] = fmt.Sprintf("failed to check image '%s': %v", con.Image, strings.TrimSpace(err.Error()))
+			} else if digest == "" {
+				plan.ImageErrors[name] = fmt.Sprintf("image '%s' is not found", con.Image)
+			}
+		}
+	}
+}
+
+// PinImageDigests resolves images of containers to digests, and replaces them with 'image@digest'.
+func (s ConcreteTaskService) PinImageDigests(plans []*types.TaskUpdatePlan) error {
+
+	for _, plan := range plans {
+		plan.PinnedImages = map[string]*types.ContainerLock{}
+		for name, con := range plan.NewContainers {
+			ref, err := types.ParseImageReference(con.Image)
+			if err != nil {
+				return fmt.Errorf("Container '%s' of task definition '%s': %v. ", name, plan.Name, err)
+			}
+
+			digest := ref.Digest
+			if digest == "" {
+				digest, err = s.registry.resolveDigest(ref)
+				if err != nil {
+					return err
+				}
+				if digest == "" {
+					return fmt.Errorf("Image '%s' of container '%s' is not found. ", con.Image, name)
+				}
+			}
+
+			plan.PinnedImages[name] = &types.ContainerLock{
+				Image:  con.Image,
+				Digest: digest,
+			}
+			pinned := ref.WithDigest(digest)
+			if pinned != con.Image {
+				logger.Main.Infof("Pinned image '%s' to '%s'.", con.Image, color.CyanString(pinned))
+				con.Image = pinned
+			}
+		}
+	}
+
+	return nil
+}
+
+// CompareLock describes differences of images and parameters of plan from the lock file.
+// Images locked with the same tag are resolved again, to report tags moved to other digests.
+// It returns nothing if the task definition is not locked.
+func (s ConcreteTaskService) CompareLock(plan *types.TaskUpdatePlan) ([]string, error) {
+
+	lock, err := ReadLockFile(s.projectDir, s.overlay)
+	if err != nil {
+		return []string{}, err
+	}
+
+	locked, ok := lock.Tasks[plan.Name]
+	if !ok {
+		return []string{}, nil
+	}
+
+	digests := map[string]string{}
+	for name, con := range plan.NewContainers {
+		pinned, ok := locked.Containers[name]
+		if !ok || pinned.Image != con.Image {
+			continue
+		}
+
+		ref, err := types.ParseImageReference(con.Image)
+		if err != nil {
+			continue
+		}
+		if ref.Digest != "" {
+			digests[name] = ref.Digest
+			continue
+		}
+
+		digest, err := s.registry.resolveDigest(ref)
+		if err != nil {
+			digests[name] = fmt.Sprintf("(cannot be resolved: %v)", strings.TrimSpace(err.Error()))
+		} else if digest == "" {
+			digests[name] = "(not found)"
+		} else {
+			digests[name] = digest
+		}
+	}
+
+	return compareTaskLock(locked, plan, s.params, digests), nil
+}
+
+// WriteLock writes pinned images, parameters and registered revisions of plans to the lock file.
+// Task definitions which are not in plans are kept.
+func (s ConcreteTaskService) WriteLock(plans []*types.TaskUpdatePlan, results []*awsecs.TaskDefinition) error {
+
+	lock, err := ReadLockFile(s.projectDir, s.overlay)
+	if err != nil {
+		return err
+	}
+
+	revisions := map[string]int64{}
+	for _, result := range results {
+		revisions[*result.Family] = *result.Revision
+	}
+
+	for _, plan := range plans {
+		lock.Tasks[plan.Name] = &types.TaskLock{
+			Revision:   revisions[plan.Name],
+			Parameters: lockParameters(plan.Parameters, s.params),
+			Containers: plan.PinnedImages,
+		}
+	}
+
+	if err := writeLockFile(s.projectDir, s.overlay, lock); err != nil {
+		return err
+	}
+	logger.Main.Infof("Wrote %s", LockFilePath(s.projectDir, s.overlay))
+
+	return nil
+}
+
+// GetRevisionHistory returns active revisions of family from the newest. All of them are returned if limit is 0.
+func (s ConcreteTaskService) GetRevisionHistory(family string, limit int) ([]*types.TaskRevision, error) {
+
+	arns, err := s.ecsCli.ListTaskDefinitions(family)
+	if err != nil {
+		return []*types.TaskRevision{}, err
+	}
+	if limit > 0 && len(arns) > limit {
+		arns = arns[:limit]
+	}
+
+	revisions := []*types.TaskRevision{}
+	for _, arn := range arns {
+		td, err := s.ecsCli.DescribeTaskDefinition(*arn)
+		if err != nil {
+			return []*types.TaskRevision{}, err
+		}
+
+		images := map[string]string{}
+		for _, con := range td.ContainerDefinitions {
+			images[*con.Name] = *con.Image
+		}
+
+		revisions = append(revisions, &types.TaskRevision{
+			Family:       *td.Family,
+			Revision:     *td.Revision,
+			RegisteredAt: td.RegisteredAt,
+			Images:       images,
+		})
+	}
+
+	return revisions, nil
+}
+
+// DiffRevisions compares fields of two revisions of family. Revisions which have been deregistered can be compared.
+func (s ConcreteTaskService) DiffRevisions(family string, from int64, to int64) ([]string, error) {
+
+	fromDef, err := s.ecsCli.DescribeTaskDefinition(fmt.Sprintf("%s:%d", family, from))
+	if err != nil {
+		return []string{}, err
+	}
+
+	toDef, err := s.ecsCli.DescribeTaskDefinition(fmt.Sprintf("%s:%d", family, to))
+	if err != nil {
+		return []string{}, err
+	}
+
+	return diffTaskDefinitions(fromDef, toDef)
+}
+
+// PruneRevisions deregisters active revisions of families except the newest ones to keep.
+// Revisions which services of any cluster use, or which are pinned in service files or lock files of the project,
+// are kept. Nothing is deregistered if dryRun is true.
+func (s ConcreteTaskService) PruneRevisions(families []string, keep int, dryRun bool) ([]*types.TaskRevision, error) {
+
+	pruned := []*types.TaskRevision{}
+	if keep < 1 {
+		return pruned, fmt.Errorf("At least 1 revision should be kept. ")
+	}
+
+	used, err := searchServiceTaskDefinitions(s.ecsCli)
+	if err != nil {
+		return pruned, err
+	}
+
+	pinned, err := searchPinnedTaskDefinitions(s.projectDir, s.overlay, s.params)
+	if err != nil {
+		return pruned, err
+	}
+
+	for _, family := range families {
+		arns, err := s.ecsCli.ListTaskDefinitions(family)
+		if err != nil {
+			return pruned, err
+		}
+		if len(arns) <= keep {
+			continue
+		}
+
+		for _, arn := range arns[keep:] {
+			revision := &types.TaskRevision{
+				Family:   family,
+				Revision: revisionOf(*arn),
+			}
+
+			if services, ok := used[*arn]; ok {
+				logger.Main.Infof("Keep '%v', which is used by %s.", revision, strings.Join(services, ", "))
+				continue
+			}
+			if sources, ok := pinned[revision.String()]; ok {
+				logger.Main.Infof("Keep '%v', which is pinned in %s.", revision, strings.Join(sources, ", "))
+				continue
+			}
+
+			if !dryRun {
+				if _, err := s.ecsCli.DeregisterTaskDefinition(*arn); err != nil {
+					return pruned, err
+				}
+			}
+			pruned = append(pruned, revision)
+		}
+	}
+
+	return pruned, nil
+}
@@ -1,73 +1,229 @@
 package types
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 )
 
 type BlueGreenPlan struct {
+	Mode       string
 	Blue       *ServiceSet
 	Green      *ServiceSet
 	PrimaryElb string
 	StandbyElb string
 	ChainElb   []BlueGreenChainElb
 	ElbV2      *BlueGreenElbV2
+	// TargetGroups is resolved from ElbV2 at planning
+	TargetGroups []*TargetGroupPair
+	// ListenerRules are switched between target groups of blue and green in service mode
+	ListenerRules []*elbv2.Rule
+	// Parameters are referred in bluegreen/<name>.yml
+	Parameters []string
+}
+
+type TargetGroupPair struct {
+	PrimaryName string
+	PrimaryARN  string
+	StandbyName string
+	StandbyARN  string
 }
 
 type ServiceSet struct {
-	CurrentService    *ecs.Service
+	CurrentServices   []*ecs.Service
 	NewService        *BlueGreenTarget
 	AutoScalingGroup  *autoscaling.Group
 	ClusterUpdatePlan *ServiceUpdatePlan
+	TargetGroupARN    string
 }
 
 type BlueGreen struct {
-	Blue       BlueGreenTarget     `yaml:"blue"`
-	Green      BlueGreenTarget     `yaml:"green"`
-	PrimaryElb string              `yaml:"primary_elb"`
-	StandbyElb string              `yaml:"standby_elb"`
-	ChainElb   []BlueGreenChainElb `yaml:"chain_elb"`
-	ElbV2      *BlueGreenElbV2     `yaml:"elbv2"`
+	Mode       string              `yaml:"mode" description:"'cluster' switches autoscaling groups of two clusters, and 'service' switches listener rules between two services." enum:"cluster,service"`
+	Blue       BlueGreenTarget     `yaml:"blue" description:"Blue side of the deployment."`
+	Green      BlueGreenTarget     `yaml:"green" description:"Green side of the deployment."`
+	PrimaryElb string              `yaml:"primary_elb" description:"Load balancer receiving production traffic."`
+	StandbyElb string              `yaml:"standby_elb" description:"Load balancer receiving standby traffic."`
+	ChainElb   []BlueGreenChainElb `yaml:"chain_elb" description:"Additional pairs of load balancers switched together."`
+	ElbV2      *BlueGreenElbV2     `yaml:"elbv2" description:"Target groups and listener rules of Application Load Balancer."`
+	DependsOn  []string            `yaml:"depends_on" description:"Bluegreen groups deployed before this group."`
+	Parameters []string            `yaml:"-"`
+}
+
+type BlueGreenResult struct {
+	Name     string
+	From     string
+	To       string
+	Switched bool
+	Skipped  bool
+	Error    error
 }
 
 type BlueGreenChainElb struct {
-	PrimaryElb string `yaml:"primary_elb"`
-	StandbyElb string `yaml:"standby_elb"`
+	PrimaryElb string `yaml:"primary_elb" description:"Load balancer receiving production traffic."`
+	StandbyElb string `yaml:"standby_elb" description:"Load balancer receiving standby traffic."`
 }
 
 type BlueGreenElbV2 struct {
-	TargetGroups []BlueGreenTargetGroupPair `yaml:"target_groups"`
+	TargetGroups  []BlueGreenTargetGroupPair `yaml:"target_groups" description:"Pairs of target groups switched between colors."`
+	ListenerRules []string                   `yaml:"listener_rules" description:"ARNs of listener rules switched in service mode."`
 }
 
 type BlueGreenTargetGroupPair struct {
-	PrimaryGroup string `yaml:"primary_group"`
-	StandbyGroup string `yaml:"standby_group"`
+	PrimaryGroup string `yaml:"primary_group" description:"Target group receiving production traffic."`
+	StandbyGroup string `yaml:"standby_group" description:"Target group receiving standby traffic."`
 }
 
 type BlueGreenTarget struct {
-	Cluster          string `yaml:"cluster"`
-	Service          string `yaml:"service"`
-	AutoscalingGroup string `yaml:"autoscaling_group"`
+	Cluster          string             `yaml:"cluster" description:"ECS cluster of the color."`
+	Service          string             `yaml:"service" description:"ECS service of the color."`
+	Services         []string           `yaml:"services" description:"ECS services of the color."`
+	AutoscalingGroup string             `yaml:"autoscaling_group" description:"Autoscaling group of ECS instances of the color."`
+	Capacity         *BlueGreenCapacity `yaml:"capacity" description:"Capacity of the color while standby."`
+	TargetGroup      string             `yaml:"target_group" description:"Target group of the color in service mode."`
 }
 
-func (p *BlueGreenPlan) IsBlueWithPrimaryElb() bool {
+// ServiceNames returns names of services deployed at this color.
+// 'services' is preferred, and 'service' is kept for single service.
+func (t BlueGreenTarget) ServiceNames() []string {
 
-	if p.ElbV2 != nil && len(p.ElbV2.TargetGroups) > 0 {
-		for _, tg := range p.Blue.AutoScalingGroup.TargetGroupARNs {
-			topTg := p.ElbV2.TargetGroups[0]
-			// TODO name check
-			if strings.Contains(*tg, topTg.PrimaryGroup) {
-				return true
-			}
-		}
-	} else {
+	if len(t.Services) > 0 {
+		return t.Services
+	}
+
+	if t.Service != "" {
+		return []string{t.Service}
+	}
+
+	return []string{}
+}
+
+type BlueGreenCapacity struct {
+	Standby  int64 `yaml:"standby" description:"Desired capacity of autoscaling group while standby."`
+	CoolDown int64 `yaml:"cool_down" description:"Seconds to wait before scaling down to standby capacity."`
+}
+
+const (
+	ColorBlue  = "blue"
+	ColorGreen = "green"
+
+	// BlueGreenModeCluster switches autoscaling groups of two clusters between load balancers.
+	BlueGreenModeCluster = "cluster"
+	// BlueGreenModeService switches listener rules between target groups of two services in one cluster.
+	BlueGreenModeService = "service"
+)
+
+func (bg BlueGreen) IsServiceMode() bool {
+	return bg.Mode == BlueGreenModeService
+}
+
+func (p *BlueGreenPlan) IsServiceMode() bool {
+	return p.Mode == BlueGreenModeService
+}
+
+// ActiveColor returns the color attached to primary load balancer.
+// In case of ALB, all target group pairs must be attached to the same color.
+func (p *BlueGreenPlan) ActiveColor() (string, error) {
+
+	if p.IsServiceMode() {
+		return p.activeColorByListenerRules()
+	}
+
+	if len(p.TargetGroups) == 0 {
 		for _, lb := range p.Blue.AutoScalingGroup.LoadBalancerNames {
 			if *lb == p.PrimaryElb {
-				return true
+				return ColorBlue, nil
 			}
 		}
+		return ColorGreen, nil
+	}
+
+	blue := []string{}
+	green := []string{}
+	for _, pair := range p.TargetGroups {
+		if containsARN(p.Blue.AutoScalingGroup.TargetGroupARNs, pair.PrimaryARN) {
+			blue = append(blue, pair.PrimaryName)
+		} else if containsARN(p.Green.AutoScalingGroup.TargetGroupARNs, pair.PrimaryARN) {
+			green = append(green, pair.PrimaryName)
+		} else {
+			return "", fmt.Errorf("Primary target group '%s' is attached to neither blue nor green.", pair.PrimaryName)
+		}
+	}
+
+	if len(blue) > 0 && len(green) > 0 {
+		return "", fmt.Errorf("Primary target groups are inconsistent. blue: %s, green: %s", strings.Join(blue, ", "), strings.Join(green, ", "))
+	}
+
+	if len(blue) > 0 {
+		return ColorBlue, nil
+	}
+	return ColorGreen, nil
+}
+
+func (p *BlueGreenPlan) activeColorByListenerRules() (string, error) {
+
+	blue := []string{}
+	green := []string{}
+	for _, rule := range p.ListenerRules {
+		arn := ForwardTargetGroupARN(rule)
+		if arn == p.Blue.TargetGroupARN {
+			blue = append(blue, *rule.RuleArn)
+		} else if arn == p.Green.TargetGroupARN {
+			green = append(green, *rule.RuleArn)
+		} else {
+			return "", fmt.Errorf("Listener rule '%s' forwards to neither blue nor green target group.", *rule.RuleArn)
+		}
+	}
+
+	if len(blue) > 0 && len(green) > 0 {
+		return "", fmt.Errorf("Listener rules are inconsistent. blue: %s, green: %s", strings.Join(blue, ", "), strings.Join(green, ", "))
+	}
+
+	if len(blue) > 0 {
+		return ColorBlue, nil
+	}
+	return ColorGreen, nil
+}
+
+// ForwardTargetGroupARN returns target group ARN which the rule forwards to. For weighted target groups
+// of ForwardConfig, it is the only group with positive weight, and empty if traffic is split between groups.
+func ForwardTargetGroupARN(rule *elbv2.Rule) string {
+
+	for _, action := range rule.Actions {
+		if *action.Type != elbv2.ActionTypeEnumForward {
+			continue
+		}
+		if action.TargetGroupArn != nil {
+			return *action.TargetGroupArn
+		}
+		if action.ForwardConfig == nil {
+			continue
+		}
+
+		arn := ""
+		for _, group := range action.ForwardConfig.TargetGroups {
+			if group.TargetGroupArn == nil || group.Weight != nil && *group.Weight == 0 {
+				continue
+			}
+			if arn != "" {
+				return ""
+			}
+			arn = *group.TargetGroupArn
+		}
+		return arn
+	}
+
+	return ""
+}
+
+func containsARN(arns []*string, arn string) bool {
+
+	for _, a := range arns {
+		if *a == arn {
+			return true
+		}
 	}
 
 	return false
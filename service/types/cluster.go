@@ -1,6 +1,8 @@
 package types
 
 import (
+	"strconv"
+
 	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"gopkg.in/guregu/null.v3"
@@ -15,29 +17,49 @@ const (
 )
 
 type Cluster struct {
-	Name     string
-	Services map[string]Service
+	Name       string
+	Services   map[string]Service
+	Parameters []string
 }
 
 type Service struct {
 	Name                  string
-	TaskDefinition        string                `yaml:"task_definition"`
-	DesiredCount          int64                 `yaml:"desired_count"`
-	KeepDesiredCount      bool                  `yaml:"keep_desired_count"`
-	LoadBalancers         []LoadBalancer        `yaml:"load_balancers"`
-	MinimumHealthyPercent null.Int              `yaml:"minimum_healthy_percent"`
-	MaximumPercent        null.Int              `yaml:"maximum_percent"`
-	Role                  string                `yaml:"role"`
-	AutoScaling           *AutoScaling          `yaml:"autoscaling"`
-	PlacementConstraints  []PlacementConstraint `yaml:"placement_constraints"`
-	PlacementStrategy     []PlacementStrategy   `yaml:"placement_strategy"`
+	TaskDefinition        string                `yaml:"task_definition" description:"Name of task definition under task/, with optional revision like 'api:43' or 'api:latest'. The newest active revision is used without it."`
+	DesiredCount          int64                 `yaml:"desired_count" description:"Number of tasks to run."`
+	KeepDesiredCount      bool                  `yaml:"keep_desired_count" description:"Keeps current desired count of running service on update."`
+	LoadBalancers         []LoadBalancer        `yaml:"load_balancers" description:"Load balancers attached to the service."`
+	MinimumHealthyPercent null.Int              `yaml:"minimum_healthy_percent" description:"Lower limit of running tasks during deployment, in percent of desired count."`
+	MaximumPercent        null.Int              `yaml:"maximum_percent" description:"Upper limit of running tasks during deployment, in percent of desired count."`
+	Role                  string                `yaml:"role" description:"IAM role for load balancers."`
+	AutoScaling           *AutoScaling          `yaml:"autoscaling" description:"Application Auto Scaling of the service."`
+	PlacementConstraints  []PlacementConstraint `yaml:"placement_constraints" description:"Placement constraints of tasks."`
+	PlacementStrategy     []PlacementStrategy   `yaml:"placement_strategy" description:"Placement strategy of tasks."`
+}
+
+// Family returns name of task definition of the service, without revision.
+func (s Service) Family() string {
+	family, _ := SplitTaskDefinition(s.TaskDefinition)
+	return family
+}
+
+// PinnedRevision returns revision of 'family:revision'. It is false for 'family' and 'family:latest',
+// which use the newest active revision.
+func (s Service) PinnedRevision() (int64, bool) {
+
+	_, revision := SplitTaskDefinition(s.TaskDefinition)
+	n, err := strconv.ParseInt(revision, 10, 64)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+
+	return n, true
 }
 
 type LoadBalancer struct {
-	Name           null.String `yaml:"name"`
-	ContainerName  string      `yaml:"container_name"`
-	ContainerPort  int64       `yaml:"container_port"`
-	TargetGroupARN null.String `yaml:"target_group_arn"`
+	Name           null.String `yaml:"name" description:"Name of Classic Load Balancer."`
+	ContainerName  string      `yaml:"container_name" description:"Container attached to the load balancer."`
+	ContainerPort  int64       `yaml:"container_port" description:"Port of the container attached to the load balancer."`
+	TargetGroupARN null.String `yaml:"target_group_arn" description:"ARN of target group of Application Load Balancer."`
 }
 
 type ServiceStack struct {
@@ -50,24 +72,38 @@ type ServiceUpdatePlan struct {
 	InstanceARNs    []*string
 	CurrentServices map[string]*ServiceStack
 	NewServices     map[string]*Service
+	Parameters      []string
+	// TaskDefinitions are revisions of task definitions which services use, as 'family:revision' by service.
+	TaskDefinitions map[string]string
+}
+
+// TaskDefinitionOf returns revision of task definition which the service uses.
+// It is task_definition of the service if the revision is not resolved.
+func (p ServiceUpdatePlan) TaskDefinitionOf(service *Service) string {
+
+	if td, ok := p.TaskDefinitions[service.Name]; ok {
+		return td
+	}
+
+	return service.TaskDefinition
 }
 
 type AutoScaling struct {
-	Target *ServiceScalableTarget `yaml:"target"`
+	Target *ServiceScalableTarget `yaml:"target" description:"Scalable target of the service."`
 }
 
 type ServiceScalableTarget struct {
-	MinCapacity uint   `yaml:"min_capacity"`
-	MaxCapacity uint   `yaml:"max_capacity"`
-	Role        string `yaml:"role"`
+	MinCapacity uint   `yaml:"min_capacity" description:"Minimum number of tasks."`
+	MaxCapacity uint   `yaml:"max_capacity" description:"Maximum number of tasks."`
+	Role        string `yaml:"role" description:"IAM role for load balancers."`
 }
 
 type PlacementConstraint struct {
-	Expression string `yaml:"expression"`
-	Type       string `yaml:"type"`
+	Expression string `yaml:"expression" description:"Cluster query language expression."`
+	Type       string `yaml:"type" description:"Type of constraint." enum:"distinctInstance,memberOf"`
 }
 
 type PlacementStrategy struct {
-	Field string `yaml:"field"`
-	Type  string `yaml:"type"`
+	Field string `yaml:"field" description:"Field to apply the strategy, like 'attribute:ecs.availability-zone' or 'memory'."`
+	Type  string `yaml:"type" description:"Type of strategy." enum:"random,spread,binpack"`
 }
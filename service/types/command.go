@@ -0,0 +1,46 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// CommandLine is 'command' or 'entry_point' of container. It is written in shell form "a b 'c d'",
+// or exec form ["a", "b", "c d"] like docker-compose.
+type CommandLine []string
+
+func (c *CommandLine) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	tokens := []string{}
+	if err := unmarshal(&tokens); err == nil {
+		*c = tokens
+		return nil
+	}
+
+	var line string
+	if err := unmarshal(&line); err != nil {
+		return err
+	}
+
+	tokens, err := shellwords.Parse(line)
+	if err != nil {
+		return fmt.Errorf("invalid command '%s': %v", line, err)
+	}
+	*c = tokens
+
+	return nil
+}
+
+// JSONSchema accepts both shell form and exec form.
+func (c CommandLine) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestCommandLine(t *testing.T) {
+
+	tests := []struct {
+		value  string
+		expect CommandLine
+	}{
+		{`bundle exec puma`, CommandLine{"bundle", "exec", "puma"}},
+		{`/bin/sh -c 'exec "$0" "$@"'`, CommandLine{"/bin/sh", "-c", `exec "$0" "$@"`}},
+		{`echo "a b" 'c d' e\ f`, CommandLine{"echo", "a b", "c d", "e f"}},
+		{`["bundle", "exec", "puma"]`, CommandLine{"bundle", "exec", "puma"}},
+		{`["sh", "-c", "echo 'a b'"]`, CommandLine{"sh", "-c", "echo 'a b'"}},
+		{`[]`, CommandLine{}},
+	}
+
+	for _, test := range tests {
+		var actual CommandLine
+		if err := yaml.Unmarshal([]byte(test.value), &actual); err != nil {
+			t.Errorf("%s: unexpected error: %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expect) {
+			t.Errorf("%s: expect %#v, but actual is %#v", test.value, test.expect, actual)
+		}
+	}
+}
+
+func TestInvalidCommandLine(t *testing.T) {
+
+	values := []string{
+		`echo 'a b`,
+		`echo "a b`,
+		`{command: echo}`,
+	}
+
+	for _, value := range values {
+		var actual CommandLine
+		if err := yaml.Unmarshal([]byte(value), &actual); err == nil {
+			t.Errorf("%s: expect error, but actual is %#v", value, actual)
+		}
+	}
+}
@@ -1,32 +1,21 @@
 package types
 
 import (
-	"strings"
-
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
 )
 
-func CreateContainerDefinition(con *ContainerDefinition) (*ecs.ContainerDefinition, []*ecs.Volume, error) {
+// CreateContainerDefinition creates container definition, and volumes of host paths which the container mounts.
+func CreateContainerDefinition(con *ContainerDefinition, taskVolumes map[string]TaskVolume) (*ecs.ContainerDefinition, []*ecs.Volume, error) {
 
 	var commands []*string
 	if len(con.Command) > 0 {
-		for _, token := range strings.Split(con.Command, " ") {
-			commands = append(commands, aws.String(token))
-		}
-	} else {
-		commands = nil
+		commands = aws.StringSlice(con.Command)
 	}
 
 	var entryPoints []*string
 	if len(con.EntryPoint) > 0 {
-		ep, err := ParseEntrypoint(con.EntryPoint)
-		if err != nil {
-			return nil, []*ecs.Volume{}, err
-		}
-		entryPoints = ep
-	} else {
-		entryPoints = nil
+		entryPoints = aws.StringSlice(con.EntryPoint)
 	}
 
 	portMappings, err := ToPortMappings(con.Ports)
@@ -34,7 +23,7 @@ func CreateContainerDefinition(con *ContainerDefinition) (*ecs.ContainerDefiniti
 		return nil, []*ecs.Volume{}, err
 	}
 
-	volumeItems, err := CreateVolumeInfoItems(con.Volumes)
+	volumeItems, err := CreateVolumeInfoItems(con.Volumes, taskVolumes)
 	if err != nil {
 		return nil, []*ecs.Volume{}, err
 	}
@@ -42,7 +31,9 @@ func CreateContainerDefinition(con *ContainerDefinition) (*ecs.ContainerDefiniti
 	mountPoints := []*ecs.MountPoint{}
 	volumes := []*ecs.Volume{}
 	for _, vp := range volumeItems {
-		volumes = append(volumes, vp.Volume)
+		if vp.Volume != nil {
+			volumes = append(volumes, vp.Volume)
+		}
 
 		mountPoints = append(mountPoints, vp.MountPoint)
 	}
@@ -57,6 +48,16 @@ func CreateContainerDefinition(con *ContainerDefinition) (*ecs.ContainerDefiniti
 		return nil, []*ecs.Volume{}, err
 	}
 
+	linuxParameters, err := CreateLinuxParameters(con)
+	if err != nil {
+		return nil, []*ecs.Volume{}, err
+	}
+
+	healthCheck, err := ToHealthCheck(con.HealthCheck)
+	if err != nil {
+		return nil, []*ecs.Volume{}, err
+	}
+
 	cd := &ecs.ContainerDefinition{
 		Cpu:                    aws.Int64(con.CPUUnits),
 		Command:                commands,
@@ -80,6 +81,13 @@ func CreateContainerDefinition(con *ContainerDefinition) (*ecs.ContainerDefiniti
 		Privileged:             aws.Bool(con.Privileged),
 		ReadonlyRootFilesystem: aws.Bool(con.ReadonlyRootFilesystem),
 		Ulimits:                ToUlimits(con.Ulimits),
+		LinuxParameters:        linuxParameters,
+		HealthCheck:            healthCheck,
+		StopTimeout:            con.StopTimeout,
+		StartTimeout:           con.StartTimeout,
+		PseudoTerminal:         aws.Bool(con.Tty),
+		Interactive:            aws.Bool(con.StdinOpen),
+		ResourceRequirements:   ToResourceRequirements(con.ResourceRequirements),
 	}
 
 	if con.Hostname != "" {
@@ -90,6 +98,9 @@ func CreateContainerDefinition(con *ContainerDefinition) (*ecs.ContainerDefiniti
 			LogDriver: aws.String(con.LogDriver),
 			Options:   aws.StringMap(con.LogOpt),
 		}
+		if len(con.LogSecretOptions) > 0 {
+			cd.LogConfiguration.SecretOptions = ToSecrets(con.LogSecretOptions)
+		}
 	}
 	if con.User != "" {
 		cd.User = aws.String(con.User)
@@ -97,6 +108,22 @@ func CreateContainerDefinition(con *ContainerDefinition) (*ecs.ContainerDefiniti
 	if con.WorkingDirectory != "" {
 		cd.WorkingDirectory = aws.String(con.WorkingDirectory)
 	}
+	if len(con.Sysctls) > 0 {
+		cd.SystemControls = ToSystemControls(con.Sysctls)
+	}
+	if con.FireLens != nil {
+		cd.FirelensConfiguration = &ecs.FirelensConfiguration{
+			Type: aws.String(con.FireLens.Type),
+		}
+		if len(con.FireLens.Options) > 0 {
+			cd.FirelensConfiguration.Options = aws.StringMap(con.FireLens.Options)
+		}
+	}
+	if con.RepositoryCredentials != "" {
+		cd.RepositoryCredentials = &ecs.RepositoryCredentials{
+			CredentialsParameter: aws.String(con.RepositoryCredentials),
+		}
+	}
 
 	return cd, volumes, nil
 }
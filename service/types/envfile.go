@@ -0,0 +1,77 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// EnvFileSource reads variables of 'env_file' in memory.
+type EnvFileSource interface {
+	// Match returns whether the source reads the env file.
+	Match(path string) bool
+	// Read returns variables of the env file. basedir is the directory of task file.
+	Read(path string, basedir string) (map[string]string, error)
+}
+
+var (
+	envFileSources     = []EnvFileSource{}
+	envFileSourceMutex sync.Mutex
+)
+
+// RegisterEnvFileSource registers source of env files. Local file is read if no source matches.
+func RegisterEnvFileSource(source EnvFileSource) {
+	envFileSourceMutex.Lock()
+	defer envFileSourceMutex.Unlock()
+
+	envFileSources = append(envFileSources, source)
+}
+
+// ReadEnvFile reads variables of the env file with the source which matches it.
+func ReadEnvFile(path string, basedir string) (map[string]string, error) {
+	envFileSourceMutex.Lock()
+	sources := append(envFileSources, LocalEnvFileSource{})
+	envFileSourceMutex.Unlock()
+
+	for _, source := range sources {
+		if source.Match(path) {
+			envmap, err := source.Read(path, basedir)
+			if err != nil {
+				return map[string]string{}, fmt.Errorf("failed to read env_file '%s': %v", path, err)
+			}
+			return envmap, nil
+		}
+	}
+
+	return map[string]string{}, fmt.Errorf("env_file '%s' is not supported", path)
+}
+
+// ParseEnvFile parses content of env file.
+func ParseEnvFile(content []byte) (map[string]string, error) {
+	return godotenv.Parse(bytes.NewReader(content))
+}
+
+// LocalEnvFileSource reads local env file. Relative path is from the directory of task file.
+type LocalEnvFileSource struct{}
+
+func (s LocalEnvFileSource) Match(path string) bool {
+	return path != ""
+}
+
+func (s LocalEnvFileSource) Read(path string, basedir string) (map[string]string, error) {
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(basedir, path)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	return ParseEnvFile(content)
+}
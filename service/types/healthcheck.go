@@ -0,0 +1,79 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// HealthCheck is health check of container. Seconds are limited by ECS.
+type HealthCheck struct {
+	Command     HealthCheckCommand `yaml:"command" description:"Command to check health, as shell command string, or list starting with 'CMD' or 'CMD-SHELL'."`
+	Interval    *int64             `yaml:"interval" description:"Seconds between checks, from 5 to 300."`
+	Timeout     *int64             `yaml:"timeout" description:"Seconds to wait for a check, from 2 to 60."`
+	Retries     *int64             `yaml:"retries" description:"Failed checks until the container is unhealthy, from 1 to 10."`
+	StartPeriod *int64             `yaml:"start_period" description:"Seconds of grace period before failed checks count, from 0 to 300."`
+}
+
+// HealthCheckCommand is command of health check. Shell command string is run by 'CMD-SHELL'.
+type HealthCheckCommand []string
+
+func (c *HealthCheckCommand) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	tokens := []string{}
+	if err := unmarshal(&tokens); err == nil {
+		*c = tokens
+		return nil
+	}
+
+	var line string
+	if err := unmarshal(&line); err != nil {
+		return err
+	}
+	*c = []string{"CMD-SHELL", line}
+
+	return nil
+}
+
+// JSONSchema accepts both shell command string and list.
+func (c HealthCheckCommand) JSONSchema() map[string]interface{} {
+	return CommandLine{}.JSONSchema()
+}
+
+// ToHealthCheck converts health check of container, which is nil without it.
+func ToHealthCheck(hc *HealthCheck) (*ecs.HealthCheck, error) {
+
+	if hc == nil {
+		return nil, nil
+	}
+
+	if len(hc.Command) < 2 || (hc.Command[0] != "CMD" && hc.Command[0] != "CMD-SHELL") {
+		return nil, fmt.Errorf("command of health_check should be shell command string, or list starting with 'CMD' or 'CMD-SHELL'")
+	}
+
+	limits := []struct {
+		name  string
+		value *int64
+		min   int64
+		max   int64
+	}{
+		{"interval", hc.Interval, 5, 300},
+		{"timeout", hc.Timeout, 2, 60},
+		{"retries", hc.Retries, 1, 10},
+		{"start_period", hc.StartPeriod, 0, 300},
+	}
+	for _, limit := range limits {
+		if limit.value != nil && (*limit.value < limit.min || *limit.value > limit.max) {
+			return nil, fmt.Errorf("%s of health_check should be from %d to %d", limit.name, limit.min, limit.max)
+		}
+	}
+
+	return &ecs.HealthCheck{
+		Command:     aws.StringSlice(hc.Command),
+		Interval:    hc.Interval,
+		Timeout:     hc.Timeout,
+		Retries:     hc.Retries,
+		StartPeriod: hc.StartPeriod,
+	}, nil
+}
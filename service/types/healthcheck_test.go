@@ -0,0 +1,63 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"gopkg.in/yaml.v2"
+)
+
+func TestToHealthCheck(t *testing.T) {
+
+	tests := []struct {
+		value  string
+		expect []string
+	}{
+		{"command: curl -f http://localhost/ || exit 1", []string{"CMD-SHELL", "curl -f http://localhost/ || exit 1"}},
+		{"command: [CMD, curl, -f, http://localhost/]", []string{"CMD", "curl", "-f", "http://localhost/"}},
+	}
+
+	for _, test := range tests {
+		hc := &HealthCheck{}
+		if err := yaml.Unmarshal([]byte(test.value+"\ninterval: 30\nretries: 3"), hc); err != nil {
+			t.Errorf("%s: unexpected error: %v", test.value, err)
+			continue
+		}
+
+		actual, err := ToHealthCheck(hc)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(aws.StringValueSlice(actual.Command), test.expect) {
+			t.Errorf("%s: expect command %v, but actual is %v", test.value, test.expect, aws.StringValueSlice(actual.Command))
+		}
+		if aws.Int64Value(actual.Interval) != 30 || aws.Int64Value(actual.Retries) != 3 || actual.Timeout != nil {
+			t.Errorf("%s: expect interval 30 and retries 3, but actual is %v", test.value, actual)
+		}
+	}
+
+	if actual, err := ToHealthCheck(nil); actual != nil || err != nil {
+		t.Errorf("expect nil without health_check, but actual is %v, %v", actual, err)
+	}
+}
+
+func TestToInvalidHealthCheck(t *testing.T) {
+
+	checks := []*HealthCheck{
+		{},
+		{Command: HealthCheckCommand{"curl", "-f", "http://localhost/"}},
+		{Command: HealthCheckCommand{"CMD"}},
+		{Command: HealthCheckCommand{"CMD-SHELL", "true"}, Interval: aws.Int64(1)},
+		{Command: HealthCheckCommand{"CMD-SHELL", "true"}, Timeout: aws.Int64(61)},
+		{Command: HealthCheckCommand{"CMD-SHELL", "true"}, Retries: aws.Int64(0)},
+		{Command: HealthCheckCommand{"CMD-SHELL", "true"}, StartPeriod: aws.Int64(301)},
+	}
+
+	for _, hc := range checks {
+		if _, err := ToHealthCheck(hc); err == nil {
+			t.Errorf("expect error of %v, but actual is nil", hc)
+		}
+	}
+}
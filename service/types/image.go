@@ -0,0 +1,140 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DockerHubRegistry is the registry of images without registry host, like 'nginx:latest'.
+const DockerHubRegistry = "registry-1.docker.io"
+
+// dockerHubAliases are hosts which images of Docker Hub are written with, like 'docker.io/nginx'.
+var dockerHubAliases = map[string]bool{
+	"docker.io":       true,
+	"index.docker.io": true,
+	DockerHubRegistry: true,
+}
+
+var (
+	ecrRegistryPattern = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+	digestPattern      = regexp.MustCompile(`^[a-z0-9]+:[a-f0-9]{32,}$`)
+	// repositoryPattern and tagPattern are the charset of docker.
+	repositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagPattern        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// ImageReference is 'image' of container, as '[registry/]repository[:tag][@digest]'.
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageReference parses image like docker. The first component of the name is registry
+// if it has '.' or ':', or is 'localhost', and images of Docker Hub, including 'docker.io/...',
+// are in 'library' by default.
+func ParseImageReference(image string) (*ImageReference, error) {
+
+	if image == "" || strings.TrimSpace(image) != image {
+		return nil, fmt.Errorf("invalid image '%s'", image)
+	}
+
+	ref := &ImageReference{}
+	name := image
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !digestPattern.MatchString(ref.Digest) {
+			return nil, fmt.Errorf("invalid digest of image '%s'", image)
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			name = name[i+1:]
+		}
+	}
+
+	if ref.Registry == "" || dockerHubAliases[ref.Registry] {
+		ref.Registry = DockerHubRegistry
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+
+	if name == "" || ref.Tag == "" && strings.HasSuffix(image, ":") {
+		return nil, fmt.Errorf("invalid image '%s'", image)
+	}
+	if !repositoryPattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid repository name of image '%s'", image)
+	}
+	if ref.Tag != "" && !tagPattern.MatchString(ref.Tag) {
+		return nil, fmt.Errorf("invalid tag '%s' of image '%s'", ref.Tag, image)
+	}
+	ref.Repository = name
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	return ref, nil
+}
+
+// ECR returns registry ID and region, if the image is in ECR.
+func (r ImageReference) ECR() (string, string, bool) {
+
+	tokens := ecrRegistryPattern.FindStringSubmatch(r.Registry)
+	if tokens == nil {
+		return "", "", false
+	}
+
+	return tokens[1], tokens[2], true
+}
+
+// Reference is the tag or the digest, which is used to get the manifest.
+func (r ImageReference) Reference() string {
+
+	if r.Digest != "" {
+		return r.Digest
+	}
+
+	return r.Tag
+}
+
+// WithDigest returns the image pinned to digest, as 'registry/repository@digest'.
+// Registry and 'library' of Docker Hub are omitted as docker.
+func (r ImageReference) WithDigest(digest string) string {
+	return fmt.Sprintf("%s@%s", r.name(), digest)
+}
+
+func (r ImageReference) String() string {
+
+	image := r.name()
+	if r.Tag != "" {
+		image += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		image += "@" + r.Digest
+	}
+
+	return image
+}
+
+func (r ImageReference) name() string {
+
+	if r.Registry != DockerHubRegistry {
+		return r.Registry + "/" + r.Repository
+	}
+
+	return strings.TrimPrefix(r.Repository, "library/")
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestParseImageReference(t *testing.T) {
+
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		image  string
+		expect ImageReference
+		name   string
+	}{
+		{"nginx", ImageReference{DockerHubRegistry, "library/nginx", "latest", ""}, "nginx:latest"},
+		{"nginx:1.13", ImageReference{DockerHubRegistry, "library/nginx", "1.13", ""}, "nginx:1.13"},
+		{"openfresh/api:1.0", ImageReference{DockerHubRegistry, "openfresh/api", "1.0", ""}, "openfresh/api:1.0"},
+		{"docker.io/nginx:1.13", ImageReference{DockerHubRegistry, "library/nginx", "1.13", ""}, "nginx:1.13"},
+		{"index.docker.io/openfresh/api", ImageReference{DockerHubRegistry, "openfresh/api", "latest", ""}, "openfresh/api:latest"},
+		{"nginx@" + digest, ImageReference{DockerHubRegistry, "library/nginx", "", digest}, "nginx@" + digest},
+		{"nginx:1.13@" + digest, ImageReference{DockerHubRegistry, "library/nginx", "1.13", digest}, "nginx:1.13@" + digest},
+		{"registry:5000/app", ImageReference{"registry:5000", "app", "latest", ""}, "registry:5000/app:latest"},
+		{"registry:5000/team/app:2.0", ImageReference{"registry:5000", "team/app", "2.0", ""}, "registry:5000/team/app:2.0"},
+		{"localhost/app:dev", ImageReference{"localhost", "app", "dev", ""}, "localhost/app:dev"},
+		{"localhost:5000/app", ImageReference{"localhost:5000", "app", "latest", ""}, "localhost:5000/app:latest"},
+		{"123456789012.dkr.ecr.ap-northeast-1.amazonaws.com/api:1.0", ImageReference{"123456789012.dkr.ecr.ap-northeast-1.amazonaws.com", "api", "1.0", ""}, "123456789012.dkr.ecr.ap-northeast-1.amazonaws.com/api:1.0"},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseImageReference(test.image)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.image, err)
+			continue
+		}
+		if *actual != test.expect {
+			t.Errorf("%s: expect %#v, but actual is %#v", test.image, test.expect, *actual)
+		}
+		if actual.String() != test.name {
+			t.Errorf("%s: expect string '%s', but actual is '%s'", test.image, test.name, actual.String())
+		}
+	}
+}
+
+func TestParseInvalidImageReference(t *testing.T) {
+
+	images := []string{
+		"",
+		" nginx",
+		"nginx:",
+		"nginx@sha256:xyz",
+		"localhost:5000/",
+		"Nginx",
+		"nginx:1.13:1",
+		"nginx:-1",
+		"team//app",
+		"app-:1.0",
+		"app:${ssm:/app/tag}",
+		"nginx:${file:./VERSION}",
+		"${REGISTRY}/app:1.0",
+	}
+
+	for _, image := range images {
+		if _, err := ParseImageReference(image); err == nil {
+			t.Errorf("expect error of '%s'", image)
+		}
+	}
+}
+
+func TestImageReferenceECR(t *testing.T) {
+
+	ref, err := ParseImageReference("123456789012.dkr.ecr.ap-northeast-1.amazonaws.com/team/api:1.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	registryID, region, ok := ref.ECR()
+	if !ok || registryID != "123456789012" || region != "ap-northeast-1" {
+		t.Errorf("expect ECR of 123456789012 in ap-northeast-1, but actual is %s, %s, %v", registryID, region, ok)
+	}
+
+	ref, err = ParseImageReference("registry:5000/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, ok := ref.ECR(); ok {
+		t.Errorf("expect registry:5000 is not ECR")
+	}
+}
+
+func TestImageReferenceWithDigest(t *testing.T) {
+
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := map[string]string{
+		"nginx:1.13":                  "nginx@" + digest,
+		"docker.io/openfresh/api:1.0": "openfresh/api@" + digest,
+		"registry:5000/app:2.0":       "registry:5000/app@" + digest,
+		"localhost/app@" + digest:     "localhost/app@" + digest,
+	}
+
+	for image, expect := range tests {
+		ref, err := ParseImageReference(image)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", image, err)
+			continue
+		}
+		if actual := ref.WithDigest(digest); actual != expect {
+			t.Errorf("%s: expect '%s', but actual is '%s'", image, expect, actual)
+		}
+	}
+}
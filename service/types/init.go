@@ -1,6 +1,8 @@
 package types
 
 import (
+	"sort"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
 )
@@ -19,3 +21,23 @@ func ToKeyValuePairs(values map[string]string) []*ecs.KeyValuePair {
 
 	return pairs
 }
+
+// ToSecrets converts values by name, which are ARNs of Secrets Manager secrets or SSM parameters, into secrets.
+func ToSecrets(values map[string]string) []*ecs.Secret {
+
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	secrets := []*ecs.Secret{}
+	for _, name := range names {
+		secrets = append(secrets, &ecs.Secret{
+			Name:      aws.String(name),
+			ValueFrom: aws.String(values[name]),
+		})
+	}
+
+	return secrets
+}
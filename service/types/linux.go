@@ -0,0 +1,277 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+var memorySizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([bkmgBKMG]?)[bB]?$`)
+
+// MemorySize is size of memory in MiB. It is written as integer of MiB, or string with unit like '64m' and '1g'.
+type MemorySize int64
+
+func (s *MemorySize) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var n int64
+	if err := unmarshal(&n); err == nil {
+		*s = MemorySize(n)
+		return nil
+	}
+
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+
+	mib, err := ParseMemorySize(value)
+	if err != nil {
+		return err
+	}
+	*s = MemorySize(mib)
+
+	return nil
+}
+
+// JSONSchema accepts both integer and string with unit.
+func (s MemorySize) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "integer", "minimum": 0},
+			map[string]interface{}{"type": "string"},
+		},
+	}
+}
+
+// ParseMemorySize converts size like '64m' and '1g' into MiB. Size without unit is MiB.
+func ParseMemorySize(value string) (int64, error) {
+
+	tokens := memorySizePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if tokens == nil {
+		return 0, fmt.Errorf("invalid memory size '%s'", value)
+	}
+
+	n, err := strconv.ParseFloat(tokens[1], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	bytes := map[string]float64{
+		"":  1024 * 1024,
+		"b": 1,
+		"k": 1024,
+		"m": 1024 * 1024,
+		"g": 1024 * 1024 * 1024,
+	}[strings.ToLower(tokens[2])]
+
+	return int64(math.Ceil(n * bytes / (1024 * 1024))), nil
+}
+
+// ResourceRequirements are GPUs and Elastic Inference accelerators assigned to container.
+type ResourceRequirements struct {
+	GPU                   int64    `yaml:"gpu,omitempty" description:"Number of GPUs reserved for the container."`
+	InferenceAccelerators []string `yaml:"inference_accelerators,omitempty" description:"Device names of inference accelerators of the task."`
+}
+
+func ToResourceRequirements(r *ResourceRequirements) []*ecs.ResourceRequirement {
+
+	if r == nil {
+		return nil
+	}
+
+	values := []*ecs.ResourceRequirement{}
+	if r.GPU > 0 {
+		values = append(values, &ecs.ResourceRequirement{
+			Type:  aws.String(ecs.ResourceTypeGpu),
+			Value: aws.String(strconv.FormatInt(r.GPU, 10)),
+		})
+	}
+	for _, device := range r.InferenceAccelerators {
+		values = append(values, &ecs.ResourceRequirement{
+			Type:  aws.String(ecs.ResourceTypeInferenceAccelerator),
+			Value: aws.String(device),
+		})
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
+
+// CreateLinuxParameters creates Linux parameters from cap_add, cap_drop, init, shm_size, tmpfs and devices.
+// It returns nil if none of them is set.
+func CreateLinuxParameters(con *ContainerDefinition) (*ecs.LinuxParameters, error) {
+
+	tmpfs, err := ToTmpfsItems(con.Tmpfs)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := ToDevices(con.Devices)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(con.CapAdd) == 0 && len(con.CapDrop) == 0 && con.Init == nil && con.ShmSize == nil && len(tmpfs) == 0 && len(devices) == 0 {
+		return nil, nil
+	}
+
+	params := &ecs.LinuxParameters{
+		InitProcessEnabled: con.Init,
+	}
+	if len(con.CapAdd) > 0 || len(con.CapDrop) > 0 {
+		params.Capabilities = &ecs.KernelCapabilities{
+			Add:  aws.StringSlice(ToCapabilities(con.CapAdd)),
+			Drop: aws.StringSlice(ToCapabilities(con.CapDrop)),
+		}
+	}
+	if con.ShmSize != nil {
+		params.SharedMemorySize = aws.Int64(int64(*con.ShmSize))
+	}
+	if len(tmpfs) > 0 {
+		params.Tmpfs = tmpfs
+	}
+	if len(devices) > 0 {
+		params.Devices = devices
+	}
+
+	return params, nil
+}
+
+// ToCapabilities converts capabilities like 'CAP_SYS_ADMIN' of docker into 'SYS_ADMIN' of ECS.
+func ToCapabilities(values []string) []string {
+
+	caps := []string{}
+	for _, value := range values {
+		caps = append(caps, strings.TrimPrefix(strings.ToUpper(value), "CAP_"))
+	}
+
+	return caps
+}
+
+func ToTmpfsItems(values []string) ([]*ecs.Tmpfs, error) {
+
+	items := []*ecs.Tmpfs{}
+	for _, value := range values {
+		tmpfs, err := ToTmpfs(value)
+		if err != nil {
+			return []*ecs.Tmpfs{}, err
+		}
+		items = append(items, tmpfs)
+	}
+
+	return items, nil
+}
+
+// ToTmpfs converts 'containerPath:size=64m,mode=1777' into tmpfs mount. Size is required by ECS,
+// and other options are mount options.
+func ToTmpfs(value string) (*ecs.Tmpfs, error) {
+
+	tokens := strings.SplitN(value, ":", 2)
+	if tokens[0] == "" {
+		return nil, fmt.Errorf("tmpfs '%s' should have container path", value)
+	}
+
+	tmpfs := &ecs.Tmpfs{
+		ContainerPath: aws.String(tokens[0]),
+	}
+
+	if len(tokens) > 1 {
+		options := []string{}
+		for _, option := range strings.Split(tokens[1], ",") {
+			if strings.HasPrefix(option, "size=") {
+				size, err := ParseMemorySize(strings.TrimPrefix(option, "size="))
+				if err != nil {
+					return nil, fmt.Errorf("tmpfs '%s': %v", value, err)
+				}
+				tmpfs.Size = aws.Int64(size)
+			} else if option != "" {
+				options = append(options, option)
+			}
+		}
+		if len(options) > 0 {
+			tmpfs.MountOptions = aws.StringSlice(options)
+		}
+	}
+
+	if tmpfs.Size == nil {
+		return nil, fmt.Errorf("tmpfs '%s' should have size, like '%s:size=64m'", value, tokens[0])
+	}
+
+	return tmpfs, nil
+}
+
+func ToDevices(values []string) ([]*ecs.Device, error) {
+
+	devices := []*ecs.Device{}
+	for _, value := range values {
+		device, err := ToDevice(value)
+		if err != nil {
+			return []*ecs.Device{}, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+// ToDevice converts 'hostPath[:containerPath[:permissions]]' into device. Permissions are 'r', 'w' and 'm' like docker.
+func ToDevice(value string) (*ecs.Device, error) {
+
+	tokens := strings.Split(value, ":")
+	if tokens[0] == "" || len(tokens) > 3 {
+		return nil, fmt.Errorf("device '%s' should be 'hostPath[:containerPath[:permissions]]'", value)
+	}
+
+	device := &ecs.Device{
+		HostPath: aws.String(tokens[0]),
+	}
+	if len(tokens) > 1 && tokens[1] != "" {
+		device.ContainerPath = aws.String(tokens[1])
+	}
+	if len(tokens) > 2 {
+		permissions := []string{}
+		for _, p := range tokens[2] {
+			switch p {
+			case 'r':
+				permissions = append(permissions, ecs.DeviceCgroupPermissionRead)
+			case 'w':
+				permissions = append(permissions, ecs.DeviceCgroupPermissionWrite)
+			case 'm':
+				permissions = append(permissions, ecs.DeviceCgroupPermissionMknod)
+			default:
+				return nil, fmt.Errorf("permissions of device '%s' should be combination of 'r', 'w' and 'm'", value)
+			}
+		}
+		device.Permissions = aws.StringSlice(permissions)
+	}
+
+	return device, nil
+}
+
+func ToSystemControls(sysctls map[string]string) []*ecs.SystemControl {
+
+	names := []string{}
+	for name := range sysctls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := []*ecs.SystemControl{}
+	for _, name := range names {
+		values = append(values, &ecs.SystemControl{
+			Namespace: aws.String(name),
+			Value:     aws.String(sysctls[name]),
+		})
+	}
+
+	return values
+}
@@ -0,0 +1,18 @@
+package types
+
+// LockFile records images pinned to digests and parameters of task definitions applied with '--pin-digests'.
+type LockFile struct {
+	Tasks map[string]*TaskLock `yaml:"tasks"`
+}
+
+type TaskLock struct {
+	Revision   int64                     `yaml:"revision,omitempty"`
+	Parameters map[string]string         `yaml:"parameters,omitempty"`
+	Containers map[string]*ContainerLock `yaml:"containers"`
+}
+
+// ContainerLock is image of container written in task file, and the digest which it is pinned to.
+type ContainerLock struct {
+	Image  string `yaml:"image"`
+	Digest string `yaml:"digest"`
+}
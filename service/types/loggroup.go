@@ -0,0 +1,100 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LogRetentionDays are retention in days which CloudWatch Logs accepts.
+var LogRetentionDays = []int64{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1827, 2192, 2557, 2922, 3288, 3653}
+
+// LogGroup is CloudWatch Logs group which containers with awslogs log driver write to.
+type LogGroup struct {
+	Name            string
+	Region          string
+	RetentionInDays *int64
+}
+
+func (g LogGroup) String() string {
+
+	desc := g.Name
+	if g.Region != "" {
+		desc += fmt.Sprintf(" (%s)", g.Region)
+	}
+	if g.RetentionInDays != nil {
+		desc += fmt.Sprintf(" retention=%d days", *g.RetentionInDays)
+	}
+
+	return desc
+}
+
+// ToLogGroups returns log groups of containers with awslogs log driver, sorted by region and name.
+// Containers sharing a group should have the same log_retention_days.
+func ToLogGroups(containers map[string]*ContainerDefinition) ([]*LogGroup, error) {
+
+	names := []string{}
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := []*LogGroup{}
+	for _, name := range names {
+		con := containers[name]
+		if con.LogDriver != "awslogs" || con.LogOpt["awslogs-group"] == "" {
+			continue
+		}
+
+		groups = append(groups, &LogGroup{
+			Name:            con.LogOpt["awslogs-group"],
+			Region:          con.LogOpt["awslogs-region"],
+			RetentionInDays: con.LogRetentionDays,
+		})
+	}
+
+	return MergeLogGroups(groups)
+}
+
+// MergeLogGroups merges log groups of the same region and name, sorted by region and name.
+// Groups of the same name should have the same retention.
+func MergeLogGroups(groups []*LogGroup) ([]*LogGroup, error) {
+
+	merged := map[string]*LogGroup{}
+	keys := []string{}
+	for _, group := range groups {
+		key := group.Region + ":" + group.Name
+		current, ok := merged[key]
+		if !ok {
+			copied := *group
+			merged[key] = &copied
+			keys = append(keys, key)
+			continue
+		}
+
+		if current.RetentionInDays == nil {
+			current.RetentionInDays = group.RetentionInDays
+		} else if group.RetentionInDays != nil && *current.RetentionInDays != *group.RetentionInDays {
+			return nil, fmt.Errorf("log group '%s' has different log_retention_days %d and %d", group.Name, *current.RetentionInDays, *group.RetentionInDays)
+		}
+	}
+
+	sort.Strings(keys)
+	values := []*LogGroup{}
+	for _, key := range keys {
+		values = append(values, merged[key])
+	}
+
+	return values, nil
+}
+
+// IsValidLogRetentionDays checks days is one of LogRetentionDays.
+func IsValidLogRetentionDays(days int64) bool {
+
+	for _, d := range LogRetentionDays {
+		if d == days {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestMergeLogGroups(t *testing.T) {
+
+	groups, err := MergeLogGroups([]*LogGroup{
+		{Name: "/ecs/web"},
+		{Name: "/ecs/api", RetentionInDays: aws.Int64(7)},
+		{Name: "/ecs/api"},
+		{Name: "/ecs/api", Region: "us-east-1"},
+		{Name: "/ecs/web", RetentionInDays: aws.Int64(30)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expect := []string{
+		"/ecs/api retention=7 days",
+		"/ecs/web retention=30 days",
+		"/ecs/api (us-east-1)",
+	}
+	if len(groups) != len(expect) {
+		t.Fatalf("expect %v, but actual is %v", expect, groups)
+	}
+	for i, group := range groups {
+		if group.String() != expect[i] {
+			t.Errorf("expect '%s', but actual is '%s'", expect[i], group.String())
+		}
+	}
+
+	_, err = MergeLogGroups([]*LogGroup{
+		{Name: "/ecs/api", RetentionInDays: aws.Int64(7)},
+		{Name: "/ecs/api", RetentionInDays: aws.Int64(30)},
+	})
+	if err == nil {
+		t.Error("expect error for different log_retention_days, but actual is nil")
+	}
+}
@@ -1,9 +1,7 @@
 package types
 
 import (
-	"errors"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -11,74 +9,164 @@ import (
 	"github.com/aws/aws-sdk-go/service/ecs"
 )
 
-var portPattern = regexp.MustCompile(`^(\d+)\/(tcp|udp)$`)
-
+// MaxPortRange is the maximum number of ports of a range, since each port becomes a port mapping.
+const MaxPortRange = 100
+
+// ToPortMappings converts 'ports' into port mappings. Each value is written as follows, like docker-compose.
+//
+//	"80"                      host port 80 to container port 80
+//	"8080:80"                 host port 8080 to container port 80
+//	":80", "0:80"             dynamic host port to container port 80
+//	"127.0.0.1:8080:80"       host IP is accepted, but ignored by ECS
+//	"8000-8010:8000-8010"     ranges are expanded to each port, up to MaxPortRange
+//	"53/udp", "53:53/udp"     protocol is tcp or udp, and tcp by default
+//	"53/udp:53"               protocol after host port is also accepted
 func ToPortMappings(values []string) ([]*ecs.PortMapping, error) {
 
 	mappings := []*ecs.PortMapping{}
 	for _, value := range values {
 
-		mp, err := ToPortMapping(value)
-
+		mps, err := ExpandPortMapping(value)
 		if err != nil {
 			return []*ecs.PortMapping{}, err
 		}
 
-		mappings = append(mappings, mp)
+		mappings = append(mappings, mps...)
 	}
 
 	return mappings, nil
 }
 
+// ToPortMapping converts a value of 'ports' of a single port into port mapping. Use ExpandPortMapping for ranges.
 func ToPortMapping(value string) (*ecs.PortMapping, error) {
 
-	tokens := strings.Split(value, ":")
-	length := len(tokens)
-
-	if length == 1 {
-
-		if _, err := strconv.Atoi(tokens[0]); err != nil {
+	mappings, err := ExpandPortMapping(value)
+	if err != nil {
+		return &ecs.PortMapping{}, err
+	}
 
-			return &ecs.PortMapping{}, errors.New(fmt.Sprintf("Invalid port mapping value '%s'", tokens[0]))
-		}
+	if len(mappings) != 1 {
+		return &ecs.PortMapping{}, fmt.Errorf("Port mapping '%s' is a range of ports. ", value)
+	}
 
-		port, _ := strconv.ParseInt(tokens[0], 10, 64)
+	return mappings[0], nil
+}
 
-		return &ecs.PortMapping{
-			HostPort:      &port,
-			ContainerPort: &port,
-			Protocol:      aws.String("tcp"),
-		}, nil
+// ExpandPortMapping converts a value of 'ports' into port mappings. A range is expanded to each port.
+func ExpandPortMapping(value string) ([]*ecs.PortMapping, error) {
 
-	} else if length == 2 {
+	invalid := fmt.Errorf("Port mapping '%s' is invalid pattern.", value)
 
-		prefixTokens := portPattern.FindStringSubmatch(tokens[0])
-		suffixTokens := portPattern.FindStringSubmatch(tokens[1])
+	tokens := strings.Split(value, ":")
+	if len(tokens) > 2 && ExtractHostIP(value) == "" {
+		return []*ecs.PortMapping{}, invalid
+	}
 
-		var hPort int64
-		var cPort int64
-		var protocol string = "tcp"
+	container, protocol, ok := splitProtocol(tokens[len(tokens)-1])
+	if !ok {
+		return []*ecs.PortMapping{}, invalid
+	}
 
-		if len(prefixTokens) > 0 {
-			hPort, _ = strconv.ParseInt(prefixTokens[1], 10, 64)
-		} else {
-			hPort, _ = strconv.ParseInt(tokens[0], 10, 64)
+	host := container
+	if len(tokens) > 1 {
+		var hostProtocol string
+		if host, hostProtocol, ok = splitProtocol(tokens[len(tokens)-2]); !ok {
+			return []*ecs.PortMapping{}, invalid
+		}
+		switch {
+		case protocol == "":
+			protocol = hostProtocol
+		case hostProtocol != "" && hostProtocol != protocol:
+			return []*ecs.PortMapping{}, invalid
 		}
+	}
+	if protocol == "" {
+		protocol = "tcp"
+	}
+
+	containerPorts, err := parsePortRange(container)
+	if err != nil {
+		return []*ecs.PortMapping{}, fmt.Errorf("Port mapping '%s' is invalid: %v. ", value, err)
+	}
 
-		if len(suffixTokens) > 0 {
-			cPort, _ = strconv.ParseInt(suffixTokens[1], 10, 64)
-			protocol = suffixTokens[2]
+	hostPorts := containerPorts
+	if len(tokens) > 1 {
+		if host == "" {
+			hostPorts = make([]int64, len(containerPorts))
 		} else {
-			cPort, _ = strconv.ParseInt(tokens[1], 10, 64)
+			if hostPorts, err = parsePortRange(host); err != nil {
+				return []*ecs.PortMapping{}, fmt.Errorf("Port mapping '%s' is invalid: %v. ", value, err)
+			}
+			if len(hostPorts) != len(containerPorts) {
+				return []*ecs.PortMapping{}, fmt.Errorf("Port mapping '%s' has ranges of different sizes. ", value)
+			}
 		}
+	}
 
-		return &ecs.PortMapping{
-			HostPort:      &hPort,
-			ContainerPort: &cPort,
+	mappings := []*ecs.PortMapping{}
+	for i := range containerPorts {
+		mappings = append(mappings, &ecs.PortMapping{
+			HostPort:      aws.Int64(hostPorts[i]),
+			ContainerPort: aws.Int64(containerPorts[i]),
 			Protocol:      aws.String(protocol),
-		}, nil
+		})
+	}
+
+	return mappings, nil
+}
+
+// ExtractHostIP returns host IP of the value of 'ports', like "127.0.0.1" of "127.0.0.1:8080:80".
+func ExtractHostIP(value string) string {
+
+	tokens := strings.Split(value, ":")
+	if len(tokens) < 3 {
+		return ""
+	}
+
+	return strings.Trim(strings.Join(tokens[:len(tokens)-2], ":"), "[]")
+}
+
+// splitProtocol splits "53/udp" into "53" and "udp". Protocol is empty without it.
+func splitProtocol(value string) (string, string, bool) {
+
+	i := strings.Index(value, "/")
+	if i < 0 {
+		return value, "", true
+	}
 
-	} else {
-		return &ecs.PortMapping{}, errors.New(fmt.Sprintf("Port mapping '%s' is invalid pattern.", value))
+	protocol := value[i+1:]
+	if protocol != "tcp" && protocol != "udp" {
+		return "", "", false
 	}
+
+	return value[:i], protocol, true
+}
+
+func parsePortRange(value string) ([]int64, error) {
+
+	bounds := strings.SplitN(value, "-", 2)
+	from, err := strconv.ParseInt(bounds[0], 10, 64)
+	if err != nil {
+		return []int64{}, fmt.Errorf("invalid port '%s'", value)
+	}
+
+	to := from
+	if len(bounds) > 1 {
+		if to, err = strconv.ParseInt(bounds[1], 10, 64); err != nil {
+			return []int64{}, fmt.Errorf("invalid port range '%s'", value)
+		}
+	}
+	if from < 0 || to < from || to > 65535 {
+		return []int64{}, fmt.Errorf("invalid port range '%s'", value)
+	}
+	if to-from+1 > MaxPortRange {
+		return []int64{}, fmt.Errorf("port range '%s' has more than %d ports", value, MaxPortRange)
+	}
+
+	ports := []int64{}
+	for port := from; port <= to; port++ {
+		ports = append(ports, port)
+	}
+
+	return ports, nil
 }
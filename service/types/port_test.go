@@ -0,0 +1,121 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExpandPortMapping(t *testing.T) {
+
+	tests := []struct {
+		value  string
+		expect []string
+	}{
+		{"80", []string{"80:80/tcp"}},
+		{"8080:80", []string{"8080:80/tcp"}},
+		{":80", []string{"0:80/tcp"}},
+		{"0:80", []string{"0:80/tcp"}},
+		{"127.0.0.1:8080:80", []string{"8080:80/tcp"}},
+		{"[::1]:8080:80", []string{"8080:80/tcp"}},
+		{"8000-8002:9000-9002", []string{"8000:9000/tcp", "8001:9001/tcp", "8002:9002/tcp"}},
+		{"8000-8001", []string{"8000:8000/tcp", "8001:8001/tcp"}},
+		{":8000-8001", []string{"0:8000/tcp", "0:8001/tcp"}},
+		{"53/udp", []string{"53:53/udp"}},
+		{"5353:53/udp", []string{"5353:53/udp"}},
+		{"5353/udp:53", []string{"5353:53/udp"}},
+		{"5353/udp:53/udp", []string{"5353:53/udp"}},
+		{"80/tcp:80", []string{"80:80/tcp"}},
+		{"127.0.0.1:53:53/udp", []string{"53:53/udp"}},
+	}
+
+	for _, test := range tests {
+		mappings, err := ExpandPortMapping(test.value)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.value, err)
+			continue
+		}
+
+		actual := []string{}
+		for _, mp := range mappings {
+			actual = append(actual, fmt.Sprintf("%d:%d/%s", *mp.HostPort, *mp.ContainerPort, *mp.Protocol))
+		}
+		if strings.Join(actual, ",") != strings.Join(test.expect, ",") {
+			t.Errorf("%s: expect %v, but actual is %v", test.value, test.expect, actual)
+		}
+	}
+}
+
+func TestExpandInvalidPortMapping(t *testing.T) {
+
+	values := []string{
+		"",
+		"http",
+		"80/sctp",
+		"80:http",
+		"80/udp:80/tcp",
+		"8000-8001:9000",
+		"8001-8000",
+		"70000",
+		":8080:80",
+		"1-65535",
+		fmt.Sprintf("1-%d", MaxPortRange+1),
+	}
+
+	for _, value := range values {
+		if _, err := ExpandPortMapping(value); err == nil {
+			t.Errorf("%s: expect error, but actual is nil", value)
+		}
+	}
+
+	if _, err := ExpandPortMapping(fmt.Sprintf("1-%d", MaxPortRange)); err != nil {
+		t.Errorf("expect range of %d ports is accepted, but actual error is %v", MaxPortRange, err)
+	}
+}
+
+func TestToPortMapping(t *testing.T) {
+
+	mp, err := ToPortMapping("8080:80/udp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *mp.HostPort != 8080 || *mp.ContainerPort != 80 || *mp.Protocol != "udp" {
+		t.Errorf("expect 8080:80/udp, but actual is %v", mp)
+	}
+
+	if _, err := ToPortMapping("8000-8001"); err == nil {
+		t.Error("expect error for range, but actual is nil")
+	}
+}
+
+func TestToPortMappings(t *testing.T) {
+
+	mappings, err := ToPortMappings([]string{"80", "8000-8001:9000-9001", "53/udp"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mappings) != 4 {
+		t.Errorf("expect 4 port mappings, but actual is %v", mappings)
+	}
+
+	if _, err := ToPortMappings([]string{"80", "http"}); err == nil || !strings.Contains(err.Error(), "'http'") {
+		t.Errorf("expect error of 'http', but actual is %v", err)
+	}
+}
+
+func TestExtractHostIP(t *testing.T) {
+
+	tests := map[string]string{
+		"80":                  "",
+		"8080:80":             "",
+		"127.0.0.1:8080:80":   "127.0.0.1",
+		"127.0.0.1:53:53/udp": "127.0.0.1",
+		"[::1]:8080:80":       "::1",
+	}
+
+	for value, expect := range tests {
+		if actual := ExtractHostIP(value); actual != expect {
+			t.Errorf("%s: expect '%s', but actual is '%s'", value, expect, actual)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LatestRevision is revision of 'family:latest', which is the newest active revision as 'family'.
+const LatestRevision = "latest"
+
+// TaskRevision is a registered revision of task definition.
+type TaskRevision struct {
+	Family       string
+	Revision     int64
+	RegisteredAt *time.Time
+	// Images are images by container name.
+	Images map[string]string
+}
+
+func (r TaskRevision) String() string {
+	return fmt.Sprintf("%s:%d", r.Family, r.Revision)
+}
+
+// SplitTaskDefinition splits 'family[:revision]' into family and revision, which is empty without it.
+func SplitTaskDefinition(value string) (string, string) {
+
+	if i := strings.LastIndex(value, ":"); i >= 0 {
+		return value[:i], value[i+1:]
+	}
+
+	return value, ""
+}
+
+// TaskDefinitionName returns 'family:revision' from ARN of task definition.
+func TaskDefinitionName(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/openfresh/ecs-formation/util"
 	"gopkg.in/yaml.v2"
 )
 
@@ -11,7 +12,7 @@ func CreateServiceMap(data string) (map[string]Service, error) {
 
 	servicesMap := map[string]Service{}
 	if err := yaml.Unmarshal([]byte(data), &servicesMap); err != nil {
-		return nil, errors.New(fmt.Sprintf("%v\n\n%v", err.Error(), data))
+		return nil, errors.New(fmt.Sprintf("%v\n\n%v", err.Error(), util.MaskSecrets(data)))
 	}
 
 	for name, service := range servicesMap {
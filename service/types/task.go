@@ -3,62 +3,99 @@ package types
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/url"
-	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/aws/aws-sdk-go/service/ecs"
-	"github.com/joho/godotenv"
-	"github.com/openfresh/ecs-formation/client/s3"
+	"github.com/openfresh/ecs-formation/util"
 	"gopkg.in/yaml.v2"
 )
 
 type TaskDefinition struct {
 	Name                 string
 	ContainerDefinitions map[string]*ContainerDefinition
+	Volumes              map[string]TaskVolume
+	Parameters           []string
+}
+
+// TaskFile is content of task file, which has containers by name and task-level 'volumes'.
+// So 'volumes' cannot be used as the name of container.
+type TaskFile struct {
+	Volumes    map[string]TaskVolume          `yaml:"volumes"`
+	Containers map[string]ContainerDefinition `yaml:",inline"`
 }
 
 type ContainerDefinition struct {
 	Name                   string
-	Image                  string            `yaml:"image"`
-	Ports                  []string          `yaml:"ports"`
-	Environment            map[string]string `yaml:"environment"`
-	EnvFiles               []string          `yaml:"env_file"`
-	Links                  []string          `yaml:"links"`
-	Volumes                []string          `yaml:"volumes"`
-	VolumesFrom            []string          `yaml:"volumes_from"`
-	Memory                 *int64            `yaml:"memory"`
-	MemoryReservation      *int64            `yaml:"memory_reservation"`
-	CPUUnits               int64             `yaml:"cpu_units"`
-	Essential              bool              `yaml:"essential"`
-	EntryPoint             string            `yaml:"entry_point"`
-	Command                string            `yaml:"command"`
-	DisableNetworking      bool              `yaml:"disable_networking"`
-	DNSSearchDomains       []string          `yaml:"dns_search"`
-	DNSServers             []string          `yaml:"dns"`
-	DockerLabels           map[string]string `yaml:"labels"`
-	DockerSecurityOptions  []string          `yaml:"security_opt"`
-	ExtraHosts             []string          `yaml:"extra_hosts"`
-	Hostname               string            `yaml:"hostname"`
-	LogDriver              string            `yaml:"log_driver"`
-	LogOpt                 map[string]string `yaml:"log_opt"`
-	Privileged             bool              `yaml:"privileged"`
-	ReadonlyRootFilesystem bool              `yaml:"read_only"`
-	Ulimits                map[string]Ulimit `yaml:"ulimits"`
-	User                   string            `yaml:"user"`
-	WorkingDirectory       string            `yaml:"working_dir"`
+	Image                  string            `yaml:"image" description:"Docker image of the container."`
+	Ports                  []string          `yaml:"ports" description:"Port mappings as 'containerPort', 'hostPort:containerPort' or 'hostIp:hostPort:containerPort', ranges like '8000-8010:8000-8010' and optional '/tcp' or '/udp'."`
+	Environment            map[string]string `yaml:"environment" description:"Environment variables of the container."`
+	EnvFiles               []string          `yaml:"env_file" description:"Env files, which are local paths relative to the task file, S3 URLs or 'ssm:/path/prefix'."`
+	Links                  []string          `yaml:"links" description:"Containers linked as 'name' or 'name:alias'."`
+	Volumes                []string          `yaml:"volumes" description:"Volumes mounted as 'hostPath:containerPath' or 'name:containerPath' of task-level volumes, with optional ':ro' or ':rw'."`
+	VolumesFrom            []string          `yaml:"volumes_from" description:"Containers to mount volumes from, as 'name' with optional ':ro'."`
+	Memory                 *int64            `yaml:"memory" description:"Hard limit of memory in MiB."`
+	MemoryReservation      *int64            `yaml:"memory_reservation" description:"Soft limit of memory in MiB."`
+	CPUUnits               int64             `yaml:"cpu_units" description:"CPU units reserved for the container."`
+	Essential              bool              `yaml:"essential" description:"Whole task stops if this container stops."`
+	EntryPoint             CommandLine       `yaml:"entry_point" description:"Entry point of the container, in shell form or exec form."`
+	Command                CommandLine       `yaml:"command" description:"Command of the container, in shell form or exec form."`
+	DisableNetworking      bool              `yaml:"disable_networking" description:"Disables networking of the container."`
+	DNSSearchDomains       []string          `yaml:"dns_search" description:"DNS search domains."`
+	DNSServers             []string          `yaml:"dns" description:"DNS servers."`
+	DockerLabels           map[string]string `yaml:"labels" description:"Docker labels."`
+	DockerSecurityOptions  []string          `yaml:"security_opt" description:"Docker security options."`
+	ExtraHosts             []string          `yaml:"extra_hosts" description:"Additional hosts as 'hostname:ip'."`
+	Hostname               string            `yaml:"hostname" description:"Hostname of the container."`
+	LogDriver              string            `yaml:"log_driver" description:"Log driver of the container." enum:"json-file,syslog,journald,gelf,fluentd,awslogs,splunk,awsfirelens"`
+	LogOpt                 map[string]string `yaml:"log_opt" description:"Options of the log driver."`
+	LogSecretOptions       map[string]string `yaml:"log_secret_options" description:"Options of the log driver by name, whose values are read from ARNs of Secrets Manager secrets or SSM parameters."`
+	LogRetentionDays       *int64            `yaml:"log_retention_days" description:"Retention in days of awslogs-group, which is set when the group is created."`
+	Privileged             bool              `yaml:"privileged" description:"Runs the container with elevated privileges."`
+	ReadonlyRootFilesystem bool              `yaml:"read_only" description:"Mounts root filesystem as read only."`
+	Ulimits                map[string]Ulimit `yaml:"ulimits" description:"Ulimits by name, like 'nofile'."`
+	User                   string            `yaml:"user" description:"User inside the container."`
+	WorkingDirectory       string            `yaml:"working_dir" description:"Working directory of the command."`
+	// Linux parameters and extended settings of the container.
+	CapAdd                []string              `yaml:"cap_add" description:"Linux capabilities added to the container, like 'SYS_ADMIN'."`
+	CapDrop               []string              `yaml:"cap_drop" description:"Linux capabilities dropped from the container."`
+	Init                  *bool                 `yaml:"init" description:"Runs init process in the container to reap processes and forward signals."`
+	ShmSize               *MemorySize           `yaml:"shm_size" description:"Size of /dev/shm in MiB, or with unit like '64m'."`
+	Tmpfs                 []string              `yaml:"tmpfs" description:"Tmpfs mounts as 'containerPath:size=64m' with optional mount options like ',noexec'."`
+	Devices               []string              `yaml:"devices" description:"Host devices as 'hostPath[:containerPath[:permissions]]', where permissions are 'r', 'w' and 'm'."`
+	Sysctls               map[string]string     `yaml:"sysctls" description:"Namespaced kernel parameters, like 'net.core.somaxconn'."`
+	StopTimeout           *int64                `yaml:"stop_timeout" description:"Seconds to wait before the container is killed after it is stopped."`
+	StartTimeout          *int64                `yaml:"start_timeout" description:"Seconds to wait for dependencies of the container to be resolved."`
+	Tty                   bool                  `yaml:"tty" description:"Allocates pseudo terminal."`
+	StdinOpen             bool                  `yaml:"stdin_open" description:"Keeps stdin open."`
+	RepositoryCredentials string                `yaml:"repository_credentials" description:"ARN of Secrets Manager secret with credentials of private registry."`
+	ResourceRequirements  *ResourceRequirements `yaml:"resource_requirements" description:"GPUs and inference accelerators of the container."`
+	FireLens              *FireLens             `yaml:"firelens" description:"Makes the container log router of FireLens, which routes logs of containers with 'awsfirelens' log driver."`
+	HealthCheck           *HealthCheck          `yaml:"health_check" description:"Health check of the container."`
+	// EnvironmentSources is the origin of each variable in Environment, which is env_file or 'environment'.
+	EnvironmentSources map[string]string `yaml:"-"`
+}
+
+// FireLens is configuration of log router container.
+type FireLens struct {
+	Type    string            `yaml:"type" description:"Log router of FireLens." enum:"fluentbit,fluentd"`
+	Options map[string]string `yaml:"options" description:"Options of FireLens, like 'enable-ecs-log-metadata', 'config-file-type' and 'config-file-value'."`
 }
 
 type Ulimit struct {
-	Soft int64 `yaml:"soft"`
-	Hard int64 `yaml:"hard"`
+	Soft int64 `yaml:"soft" description:"Soft limit."`
+	Hard int64 `yaml:"hard" description:"Hard limit."`
 }
 
 type TaskUpdatePlan struct {
 	Name          string
 	NewContainers map[string]*ContainerDefinition
+	Volumes       map[string]TaskVolume
+	Parameters    []string
+	// NewLogGroups are log groups of awslogs containers which do not exist, and are created at apply.
+	NewLogGroups []*LogGroup
+	// PinnedImages are images of containers pinned to digests, which are written to the lock file.
+	PinnedImages map[string]*ContainerLock
+	// ImageErrors are problems of images by container, like images not found in registries.
+	ImageErrors map[string]string
 }
 
 type VolumeInfo struct {
@@ -66,96 +103,47 @@ type VolumeInfo struct {
 	MountPoint *ecs.MountPoint
 }
 
-func CreateTaskDefinition(taskDefName string, data string, basedir string, s3Cli s3.Client) (*TaskDefinition, error) {
+func CreateTaskDefinition(taskDefName string, data string, basedir string) (*TaskDefinition, error) {
 
-	containerMap := map[string]ContainerDefinition{}
-	if err := yaml.Unmarshal([]byte(data), &containerMap); err != nil {
-		return nil, errors.New(fmt.Sprintf("%v\n\n%v", err.Error(), data))
+	file := TaskFile{}
+	if err := yaml.Unmarshal([]byte(data), &file); err != nil {
+		return nil, errors.New(fmt.Sprintf("%v\n\n%v", err.Error(), util.MaskSecrets(data)))
 	}
 
 	containers := map[string]*ContainerDefinition{}
-	for name, container := range containerMap {
+	for name, container := range file.Containers {
 		con := container
 		con.Name = name
 
 		environment := map[string]string{}
-		if len(container.EnvFiles) > 0 {
-			for _, envfile := range container.EnvFiles {
-				var path string
-				if envfile[0:10] == "https://s3" {
-					_path, err := downloadS3(envfile, s3Cli)
-					if err != nil {
-						return nil, err
-					}
-					path = _path
-					defer os.Remove(_path)
-				} else if filepath.IsAbs(envfile) {
-					path = envfile
-				} else {
-					path = fmt.Sprintf("%s/%s", basedir, envfile)
-				}
-
-				envmap, err := readEnvFile(path)
-				if err != nil {
-					return nil, err
-				}
-
-				for key, value := range envmap {
-					environment[key] = value
-				}
+		sources := map[string]string{}
+		for _, envfile := range container.EnvFiles {
+			envmap, err := ReadEnvFile(envfile, basedir)
+			if err != nil {
+				return nil, err
+			}
+
+			for key, value := range envmap {
+				environment[key] = value
+				sources[key] = envfile
 			}
 		}
 
 		for key, value := range container.Environment {
 			environment[key] = value
+			sources[key] = "environment"
 		}
 
 		con.Environment = environment
+		con.EnvironmentSources = sources
 		containers[name] = &con
 	}
 
 	taskDef := TaskDefinition{
 		Name:                 taskDefName,
 		ContainerDefinitions: containers,
+		Volumes:              file.Volumes,
 	}
 
 	return &taskDef, nil
 }
-
-func downloadS3(path string, s3Cli s3.Client) (string, error) {
-	u, err := url.Parse(path)
-	if err != nil {
-		return "", err
-	}
-	ps := strings.Split(u.Path, "/")
-	bucket := ps[:2][1]
-	key := strings.Join(ps[2:], "/")
-
-	obj, err := s3Cli.GetObject(bucket, key)
-	if err != nil {
-		return "", err
-	}
-
-	b, err := ioutil.ReadAll(obj.Body)
-	if err != nil {
-		return "", err
-	}
-
-	tempfile, err := ioutil.TempFile("", "ecs-formation")
-	if err != nil {
-		return "", err
-	}
-	defer tempfile.Close()
-	tempfile.Write(b)
-	return tempfile.Name(), nil
-}
-
-func readEnvFile(envpath string) (map[string]string, error) {
-
-	envmap, err := godotenv.Read(envpath)
-	if err != nil {
-		return map[string]string{}, err
-	}
-
-	return envmap, nil
-}
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"fmt"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationError is a problem found in a definition file. Line is 0 if unknown.
+// Rule is ID of lint rule, and empty for syntax errors.
+type ValidationError struct {
+	File     string
+	Line     int
+	Message  string
+	Rule     string
+	Severity string
+}
+
+func (e ValidationError) Error() string {
+
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+
+	if e.Rule != "" {
+		return fmt.Sprintf("%s: %s: %s (%s)", location, e.Severity, e.Message, e.Rule)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
@@ -3,6 +3,8 @@ package types
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -52,12 +54,194 @@ func ToVolumesFrom(value string) (*ecs.VolumeFrom, error) {
 	}
 }
 
-func CreateVolumeInfoItems(values []string) ([]*VolumeInfo, error) {
+// TaskVolume is a volume in task-level 'volumes', which containers mount by name.
+// It is a bind mount of host_path, Docker volume with driver options, or EFS file system.
+// Without any configuration, it is a bind mount which Docker manages without source path.
+type TaskVolume struct {
+	HostPath      string            `yaml:"host_path,omitempty" description:"Path on container instance to bind mount."`
+	Driver        string            `yaml:"driver,omitempty" description:"Docker volume driver."`
+	Scope         string            `yaml:"scope,omitempty" description:"Docker volume is removed with the task, or shared after the task stops." enum:"task,shared"`
+	Autoprovision *bool             `yaml:"autoprovision,omitempty" description:"Creates Docker volume if it does not exist. Only for shared scope."`
+	DriverOpts    map[string]string `yaml:"driver_opts,omitempty" description:"Options of Docker volume driver."`
+	Labels        map[string]string `yaml:"labels,omitempty" description:"Labels of Docker volume."`
+	EFS           *EFSVolume        `yaml:"efs,omitempty" description:"EFS file system."`
+}
+
+type EFSVolume struct {
+	FileSystemID          string `yaml:"file_system_id,omitempty" description:"ID of EFS file system."`
+	RootDirectory         string `yaml:"root_directory,omitempty" description:"Directory of file system to mount as root."`
+	TransitEncryption     bool   `yaml:"transit_encryption,omitempty" description:"Encrypts data in transit."`
+	TransitEncryptionPort *int64 `yaml:"transit_encryption_port,omitempty" description:"Port of encrypted data in transit."`
+	AccessPointID         string `yaml:"access_point_id,omitempty" description:"ID of EFS access point."`
+	IAM                   bool   `yaml:"iam,omitempty" description:"Uses IAM role of the task to mount."`
+}
+
+func (v TaskVolume) isDockerVolume() bool {
+	return v.Driver != "" || v.Scope != "" || v.Autoprovision != nil || len(v.DriverOpts) > 0 || len(v.Labels) > 0
+}
+
+// String describes the volume in plans.
+func (v TaskVolume) String() string {
+
+	switch {
+	case v.EFS != nil:
+		desc := fmt.Sprintf("efs %s", v.EFS.FileSystemID)
+		if v.EFS.RootDirectory != "" {
+			desc += ":" + v.EFS.RootDirectory
+		}
+		if v.EFS.AccessPointID != "" {
+			desc += fmt.Sprintf(" access_point=%s", v.EFS.AccessPointID)
+		}
+		if v.EFS.TransitEncryption {
+			desc += " transit_encryption"
+		}
+		if v.EFS.IAM {
+			desc += " iam"
+		}
+		return desc
+	case v.isDockerVolume():
+		desc := "docker"
+		if v.Driver != "" {
+			desc += fmt.Sprintf(" driver=%s", v.Driver)
+		}
+		if v.Scope != "" {
+			desc += fmt.Sprintf(" scope=%s", v.Scope)
+		}
+		if v.Autoprovision != nil {
+			desc += fmt.Sprintf(" autoprovision=%v", *v.Autoprovision)
+		}
+		if len(v.DriverOpts) > 0 {
+			desc += fmt.Sprintf(" driver_opts=%v", v.DriverOpts)
+		}
+		if len(v.Labels) > 0 {
+			desc += fmt.Sprintf(" labels=%v", v.Labels)
+		}
+		return desc
+	case v.HostPath != "":
+		return fmt.Sprintf("host_path %s", v.HostPath)
+	default:
+		return "host"
+	}
+}
+
+// ToVolume converts task-level volume into ECS volume.
+func (v TaskVolume) ToVolume(name string) (*ecs.Volume, error) {
+
+	kinds := 0
+	for _, ok := range []bool{v.HostPath != "", v.isDockerVolume(), v.EFS != nil} {
+		if ok {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return nil, fmt.Errorf("volume '%s' should have only one of host_path, efs and docker volume options", name)
+	}
+
+	volume := &ecs.Volume{
+		Name: aws.String(name),
+	}
+
+	switch {
+	case v.HostPath != "":
+		volume.Host = &ecs.HostVolumeProperties{
+			SourcePath: aws.String(v.HostPath),
+		}
+	case v.isDockerVolume():
+		if v.Scope != "" && v.Scope != ecs.ScopeTask && v.Scope != ecs.ScopeShared {
+			return nil, fmt.Errorf("scope of volume '%s' should be task or shared", name)
+		}
+		if v.Autoprovision != nil && v.Scope != ecs.ScopeShared {
+			return nil, fmt.Errorf("autoprovision of volume '%s' is available only for shared scope", name)
+		}
+		conf := &ecs.DockerVolumeConfiguration{
+			Autoprovision: v.Autoprovision,
+			DriverOpts:    aws.StringMap(v.DriverOpts),
+			Labels:        aws.StringMap(v.Labels),
+		}
+		if v.Driver != "" {
+			conf.Driver = aws.String(v.Driver)
+		}
+		if v.Scope != "" {
+			conf.Scope = aws.String(v.Scope)
+		}
+		volume.DockerVolumeConfiguration = conf
+	case v.EFS != nil:
+		if v.EFS.FileSystemID == "" {
+			return nil, fmt.Errorf("file_system_id of volume '%s' is required", name)
+		}
+		conf := &ecs.EFSVolumeConfiguration{
+			FileSystemId:          aws.String(v.EFS.FileSystemID),
+			TransitEncryptionPort: v.EFS.TransitEncryptionPort,
+		}
+		if v.EFS.RootDirectory != "" {
+			conf.RootDirectory = aws.String(v.EFS.RootDirectory)
+		}
+		if v.EFS.TransitEncryption {
+			conf.TransitEncryption = aws.String(ecs.EFSTransitEncryptionEnabled)
+		}
+		if v.EFS.AccessPointID != "" || v.EFS.IAM {
+			conf.AuthorizationConfig = &ecs.EFSAuthorizationConfig{}
+			if v.EFS.AccessPointID != "" {
+				conf.AuthorizationConfig.AccessPointId = aws.String(v.EFS.AccessPointID)
+			}
+			if v.EFS.IAM {
+				conf.AuthorizationConfig.Iam = aws.String(ecs.EFSAuthorizationConfigIAMEnabled)
+			}
+		}
+		volume.EfsVolumeConfiguration = conf
+	}
+
+	return volume, nil
+}
+
+// ToVolumes converts task-level volumes into ECS volumes ordered by name.
+func ToVolumes(volumes map[string]TaskVolume) ([]*ecs.Volume, error) {
+
+	names := []string{}
+	for name := range volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := []*ecs.Volume{}
+	for _, name := range names {
+		volume, err := volumes[name].ToVolume(name)
+		if err != nil {
+			return []*ecs.Volume{}, err
+		}
+		results = append(results, volume)
+	}
+
+	return results, nil
+}
+
+// MergeVolumes removes duplicated volumes of the same name, like host path mounted by several containers.
+// Volumes of the same name must be the same.
+func MergeVolumes(volumes []*ecs.Volume) ([]*ecs.Volume, error) {
+
+	merged := []*ecs.Volume{}
+	names := map[string]*ecs.Volume{}
+	for _, volume := range volumes {
+		if current, ok := names[*volume.Name]; ok {
+			if !reflect.DeepEqual(current, volume) {
+				return []*ecs.Volume{}, fmt.Errorf("volume '%s' is defined differently", *volume.Name)
+			}
+			continue
+		}
+		names[*volume.Name] = volume
+		merged = append(merged, volume)
+	}
+
+	return merged, nil
+}
+
+// CreateVolumeInfoItems creates mount points of 'volumes' of container.
+func CreateVolumeInfoItems(values []string, taskVolumes map[string]TaskVolume) ([]*VolumeInfo, error) {
 
 	volumes := []*VolumeInfo{}
 
 	for _, value := range values {
-		vi, err := CreateVolumeInfo(value)
+		vi, err := CreateVolumeInfo(value, taskVolumes)
 
 		if err != nil {
 			return []*VolumeInfo{}, err
@@ -69,7 +253,10 @@ func CreateVolumeInfoItems(values []string) ([]*VolumeInfo, error) {
 	return volumes, nil
 }
 
-func CreateVolumeInfo(value string) (*VolumeInfo, error) {
+// CreateVolumeInfo creates mount point of 'source:containerPath', with optional ':ro' or ':rw'.
+// source is the name of task-level volume, or host path which is defined as volume named after the path.
+// Volume is nil for task-level volume.
+func CreateVolumeInfo(value string, taskVolumes map[string]TaskVolume) (*VolumeInfo, error) {
 
 	if len(value) == 0 {
 		return &VolumeInfo{}, errors.New("'volumes' element must not be empty.")
@@ -77,30 +264,42 @@ func CreateVolumeInfo(value string) (*VolumeInfo, error) {
 
 	tokens := strings.Split(value, ":")
 	length := len(tokens)
-
-	if length == 0 {
-		return &VolumeInfo{}, errors.New("'volumes' element must not be empty.")
-	}
-
-	var sourceVolume string
-	var containerPath string
-	var ro = false
-
-	if length > 2 && tokens[2] == "ro" {
-		ro = true
+	if length > 3 {
+		return &VolumeInfo{}, fmt.Errorf("'%s' is invalid volume.", value)
 	}
 
+	source := tokens[0]
+	containerPath := tokens[0]
 	if length > 1 {
 		containerPath = tokens[1]
 	}
 
-	if length == 1 {
-		containerPath = tokens[0]
+	var ro = false
+	if length > 2 {
+		switch tokens[2] {
+		case "ro":
+			ro = true
+		case "rw":
+		default:
+			return &VolumeInfo{}, fmt.Errorf("mode of volume '%s' should be ro or rw.", value)
+		}
 	}
 
-	sourceVolume = tokens[0]
-	volumeName, err := createVolumeName(&tokens[0])
+	if _, ok := taskVolumes[source]; ok {
+		if length == 1 {
+			return &VolumeInfo{}, fmt.Errorf("container path of volume '%s' is required.", value)
+		}
+
+		return &VolumeInfo{
+			MountPoint: &ecs.MountPoint{
+				SourceVolume:  aws.String(source),
+				ContainerPath: aws.String(containerPath),
+				ReadOnly:      &ro,
+			},
+		}, nil
+	}
 
+	volumeName, err := createVolumeName(&source)
 	if err != nil {
 		return &VolumeInfo{}, errors.New("'volumes' element must not be empty.")
 	}
@@ -109,7 +308,7 @@ func CreateVolumeInfo(value string) (*VolumeInfo, error) {
 		Volume: &ecs.Volume{
 			Name: aws.String(volumeName),
 			Host: &ecs.HostVolumeProperties{
-				SourcePath: aws.String(sourceVolume),
+				SourcePath: aws.String(source),
 			},
 		},
 		MountPoint: &ecs.MountPoint{
@@ -0,0 +1,551 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/openfresh/ecs-formation/service/types"
+	"github.com/openfresh/ecs-formation/util"
+)
+
+var (
+	errorLinePattern  = regexp.MustCompile(`^(?:template: .+?:(\d+):(?:\d+:)? |(?:yaml: )?line (\d+): )`)
+	unknownKeyPattern = regexp.MustCompile(`field (\S+) not found in (?:type|struct) \S+`)
+)
+
+// strictContainer and strictService accept 'extends' in addition to the fields of definitions.
+type strictContainer struct {
+	types.ContainerDefinition `yaml:",inline"`
+	Extends                   interface{} `yaml:"extends"`
+}
+
+// strictTaskFile is task file with strict containers.
+type strictTaskFile struct {
+	Volumes    map[string]types.TaskVolume `yaml:"volumes"`
+	Containers map[string]strictContainer  `yaml:",inline"`
+}
+
+type strictService struct {
+	types.Service `yaml:",inline"`
+	Extends       interface{} `yaml:"extends"`
+}
+
+type projectValidator struct {
+	projectDir   string
+	overlay      string
+	params       map[string]string
+	errors       []*types.ValidationError
+	taskFiles    map[string]*yamlFile
+	tasks        map[string]map[string]types.ContainerDefinition
+	clusterFiles map[string]*yamlFile
+	clusters     map[string]map[string]types.Service
+}
+
+// ValidateProject validates task, service and bluegreen files in project without AWS API,
+// and checks lint rules. It returns all problems found in the files.
+func ValidateProject(projectDir string, overlay string, params map[string]string) ([]*types.ValidationError, error) {
+
+	v := &projectValidator{
+		projectDir:   projectDir,
+		overlay:      overlay,
+		params:       params,
+		errors:       []*types.ValidationError{},
+		taskFiles:    map[string]*yamlFile{},
+		clusterFiles: map[string]*yamlFile{},
+	}
+
+	tasks, err := v.validateTasks()
+	if err != nil {
+		return v.errors, err
+	}
+	v.tasks = tasks
+
+	clusters, err := v.validateClusters(tasks)
+	if err != nil {
+		return v.errors, err
+	}
+	v.clusters = clusters
+
+	if err := v.validateBlueGreens(clusters); err != nil {
+		return v.errors, err
+	}
+
+	v.lint()
+
+	return v.errors, nil
+}
+
+func (v *projectValidator) validateTasks() (map[string]map[string]types.ContainerDefinition, error) {
+
+	tasks := map[string]map[string]types.ContainerDefinition{}
+
+	files, err := v.loadFiles("task", func() interface{} { return &strictTaskFile{} })
+	if err != nil {
+		return tasks, err
+	}
+
+	for _, file := range files {
+		task := types.TaskFile{}
+		if err := yaml.Unmarshal([]byte(file.Content), &task); err != nil {
+			v.addError(file.Path, err)
+			continue
+		}
+		containers := task.Containers
+		if containers == nil {
+			containers = map[string]types.ContainerDefinition{}
+		}
+		tasks[file.Name] = containers
+		v.taskFiles[file.Name] = file
+
+		if _, err := types.ToVolumes(task.Volumes); err != nil {
+			v.addAt(file, err.Error(), "volumes")
+		}
+
+		for _, name := range sortedContainerNames(containers) {
+			v.validateContainer(file, name, containers[name], containers, task.Volumes)
+		}
+		v.validateFireLens(file, containers)
+
+		pointers := map[string]*types.ContainerDefinition{}
+		for name := range containers {
+			con := containers[name]
+			pointers[name] = &con
+		}
+		if _, err := types.ToLogGroups(pointers); err != nil {
+			v.addAt(file, err.Error())
+		}
+	}
+
+	return tasks, nil
+}
+
+func (v *projectValidator) validateContainer(file *yamlFile, name string, con types.ContainerDefinition, containers map[string]types.ContainerDefinition, volumes map[string]types.TaskVolume) {
+
+	if con.Image == "" {
+		v.addAt(file, fmt.Sprintf("container '%s': 'image' is required", name), name)
+	} else if !isUnresolved(con.Image) {
+		if _, err := types.ParseImageReference(con.Image); err != nil {
+			v.addAt(file, fmt.Sprintf("container '%s': %v", name, err), name, "image")
+		}
+	}
+
+	if _, err := types.ToPortMappings(con.Ports); err != nil {
+		v.addAt(file, fmt.Sprintf("container '%s': %v", name, err), name, "ports")
+	}
+
+	if _, err := types.CreateVolumeInfoItems(con.Volumes, volumes); err != nil {
+		v.addAt(file, fmt.Sprintf("container '%s': %v", name, err), name, "volumes")
+	}
+
+	if _, err := types.ToHostEntry(con.ExtraHosts); err != nil {
+		v.addAt(file, fmt.Sprintf("container '%s': %v", name, err), name, "extra_hosts")
+	}
+
+	if len(con.LogSecretOptions) > 0 && con.LogDriver == "" {
+		v.addAt(file, fmt.Sprintf("container '%s': log_secret_options requires log_driver", name), name, "log_secret_options")
+	}
+
+	if con.LogRetentionDays != nil {
+		if con.LogDriver != "awslogs" || con.LogOpt["awslogs-group"] == "" {
+			v.addAt(file, fmt.Sprintf("container '%s': log_retention_days requires awslogs log driver with 'awslogs-group'", name), name, "log_retention_days")
+		} else if !types.IsValidLogRetentionDays(*con.LogRetentionDays) {
+			v.addAt(file, fmt.Sprintf("container '%s': log_retention_days should be one of %v", name, types.LogRetentionDays), name, "log_retention_days")
+		}
+	}
+
+	if con.FireLens != nil {
+		if con.FireLens.Type != "fluentbit" && con.FireLens.Type != "fluentd" {
+			v.addAt(file, fmt.Sprintf("container '%s': type of firelens should be fluentbit or fluentd", name), name, "firelens")
+		}
+		if con.LogDriver == "awsfirelens" {
+			v.addAt(file, fmt.Sprintf("container '%s' is log router of firelens, so it cannot use awsfirelens log driver", name), name, "log_driver")
+		}
+	}
+
+	if _, err := types.ToHealthCheck(con.HealthCheck); err != nil {
+		v.addAt(file, fmt.Sprintf("container '%s': %v", name, err), name, "health_check")
+	}
+
+	if _, err := types.ToTmpfsItems(con.Tmpfs); err != nil {
+		v.addAt(file, fmt.Sprintf("container '%s': %v", name, err), name, "tmpfs")
+	}
+
+	if _, err := types.ToDevices(con.Devices); err != nil {
+		v.addAt(file, fmt.Sprintf("container '%s': %v", name, err), name, "devices")
+	}
+
+	for _, link := range con.Links {
+		target := strings.Split(link, ":")[0]
+		if _, ok := containers[target]; !ok {
+			v.addAt(file, fmt.Sprintf("container '%s' links to unknown container '%s'", name, target), name, "links")
+		}
+	}
+
+	volumesFrom, err := types.ToVolumesFroms(con.VolumesFrom)
+	if err != nil {
+		v.addAt(file, fmt.Sprintf("container '%s': %v", name, err), name, "volumes_from")
+	}
+	for _, vf := range volumesFrom {
+		if _, ok := containers[*vf.SourceContainer]; !ok {
+			v.addAt(file, fmt.Sprintf("container '%s' has volumes_from unknown container '%s'", name, *vf.SourceContainer), name, "volumes_from")
+		}
+	}
+}
+
+// validateFireLens checks that a task has exactly one log router of FireLens, if its containers use awsfirelens log driver.
+func (v *projectValidator) validateFireLens(file *yamlFile, containers map[string]types.ContainerDefinition) {
+
+	routers := []string{}
+	users := []string{}
+	for _, name := range sortedContainerNames(containers) {
+		con := containers[name]
+		if con.FireLens != nil {
+			routers = append(routers, name)
+		}
+		if con.LogDriver == "awsfirelens" {
+			users = append(users, name)
+		}
+	}
+
+	if len(routers) > 1 {
+		v.addAt(file, fmt.Sprintf("task has %d containers with firelens (%s), but only one is allowed", len(routers), strings.Join(routers, ", ")), routers[1], "firelens")
+	}
+	if len(users) > 0 && len(routers) == 0 {
+		v.addAt(file, fmt.Sprintf("container '%s' uses awsfirelens log driver, but no container has firelens", users[0]), users[0], "log_driver")
+	}
+}
+
+func (v *projectValidator) validateClusters(tasks map[string]map[string]types.ContainerDefinition) (map[string]map[string]types.Service, error) {
+
+	clusters := map[string]map[string]types.Service{}
+
+	files, err := v.loadFiles("service", func() interface{} { return &map[string]strictService{} })
+	if err != nil {
+		return clusters, err
+	}
+
+	for _, file := range files {
+		services := map[string]types.Service{}
+		if err := yaml.Unmarshal([]byte(file.Content), &services); err != nil {
+			v.addError(file.Path, err)
+			continue
+		}
+		clusters[file.Name] = services
+		v.clusterFiles[file.Name] = file
+
+		names := []string{}
+		for name := range services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			service := services[name]
+			if service.TaskDefinition == "" {
+				v.addAt(file, fmt.Sprintf("service '%s': 'task_definition' is required", name), name)
+				continue
+			}
+
+			if _, revision := types.SplitTaskDefinition(service.TaskDefinition); revision != "" && revision != types.LatestRevision {
+				if _, ok := service.PinnedRevision(); !ok {
+					v.addAt(file, fmt.Sprintf("service '%s': revision of task definition '%s' should be number or '%s'", name, service.TaskDefinition, types.LatestRevision), name, "task_definition")
+				}
+			}
+
+			containers, ok := tasks[service.Family()]
+			if !ok {
+				v.addAt(file, fmt.Sprintf("service '%s': task definition '%s' is not found under task/", name, service.Family()), name, "task_definition")
+				continue
+			}
+
+			for _, lb := range service.LoadBalancers {
+				if _, ok := containers[lb.ContainerName]; !ok {
+					v.addAt(file, fmt.Sprintf("service '%s': container '%s' of load balancer is not found in task definition '%s'", name, lb.ContainerName, service.Family()), name, "load_balancers")
+				}
+			}
+		}
+	}
+
+	return clusters, nil
+}
+
+func (v *projectValidator) validateBlueGreens(clusters map[string]map[string]types.Service) error {
+
+	files, err := v.loadFiles("bluegreen", func() interface{} { return &types.BlueGreen{} })
+	if err != nil {
+		return err
+	}
+
+	groups := map[string]bool{}
+	for _, file := range files {
+		groups[file.Name] = true
+	}
+
+	for _, file := range files {
+		bg := types.BlueGreen{}
+		if err := yaml.Unmarshal([]byte(file.Content), &bg); err != nil {
+			v.addError(file.Path, err)
+			continue
+		}
+
+		if bg.Mode != "" && bg.Mode != types.BlueGreenModeCluster && bg.Mode != types.BlueGreenModeService {
+			v.addAt(file, fmt.Sprintf("unknown mode '%s'", bg.Mode), "mode")
+		}
+
+		for _, color := range []string{types.ColorBlue, types.ColorGreen} {
+			target := bg.Blue
+			if color == types.ColorGreen {
+				target = bg.Green
+			}
+
+			if target.Cluster == "" {
+				v.addAt(file, fmt.Sprintf("%s: 'cluster' is required", color), color)
+				continue
+			}
+
+			services, ok := clusters[target.Cluster]
+			if !ok {
+				v.addAt(file, fmt.Sprintf("%s: cluster '%s' is not found under service/", color, target.Cluster), color, "cluster")
+				continue
+			}
+
+			if len(target.ServiceNames()) == 0 {
+				v.addAt(file, fmt.Sprintf("%s: 'service' or 'services' is required", color), color)
+			}
+			for _, name := range target.ServiceNames() {
+				if _, ok := services[name]; !ok {
+					v.addAt(file, fmt.Sprintf("%s: service '%s' is not defined in cluster '%s'", color, name, target.Cluster), color)
+				}
+			}
+		}
+
+		for _, dep := range bg.DependsOn {
+			if !groups[dep] {
+				v.addAt(file, fmt.Sprintf("depends on unknown group '%s'", dep), "depends_on")
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadFiles checks every source file of kind strictly, and returns merged files which are loaded successfully.
+func (v *projectValidator) loadFiles(kind string, newStrict func() interface{}) ([]*yamlFile, error) {
+
+	baseFiles, err := walkYamlFiles(filepath.Join(projectBaseDir(v.projectDir), kind))
+	if err != nil {
+		return nil, err
+	}
+
+	overlayFiles := map[string]string{}
+	if v.overlay != "" {
+		overlayFiles, err = walkYamlFiles(filepath.Join(v.projectDir, "overlays", v.overlay, kind))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rels := map[string]bool{}
+	rendered := map[string]bool{}
+	for _, sources := range []map[string]string{baseFiles, overlayFiles} {
+		for rel, path := range sources {
+			rels[rel] = true
+			rendered[path] = v.checkSource(path, newStrict())
+		}
+	}
+
+	sorted := []string{}
+	for rel := range rels {
+		sorted = append(sorted, rel)
+	}
+	sort.Strings(sorted)
+
+	files := []*yamlFile{}
+	for _, rel := range sorted {
+		basePath, overlayPath := baseFiles[rel], overlayFiles[rel]
+		if (basePath != "" && !rendered[basePath]) || (overlayPath != "" && !rendered[overlayPath]) {
+			continue
+		}
+
+		file, err := loadYamlFile(rel, basePath, overlayPath, v.params)
+		if err != nil {
+			v.addError(basePath, err)
+			continue
+		}
+
+		if kind == "task" || kind == "service" {
+			if err := resolveExtends(v.projectDir, file, v.params); err != nil {
+				v.addError(file.Path, err)
+				continue
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// checkSource renders file and unmarshals it strictly. It returns false if file cannot be rendered.
+func (v *projectValidator) checkSource(path string, out interface{}) bool {
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		v.addError(path, err)
+		return false
+	}
+
+	merged, _, err := util.MergeYamlWithParameters(path, content, v.params)
+	if err != nil {
+		v.addError(path, err)
+		return false
+	}
+
+	if err := yaml.UnmarshalStrict([]byte(merged), out); err != nil {
+		v.addError(path, err)
+	}
+
+	return true
+}
+
+func (v *projectValidator) addError(path string, err error) {
+
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		for _, msg := range typeErr.Errors {
+			v.add(path, unknownKeyPattern.ReplaceAllString(msg, "unknown key '$1'"))
+		}
+		return
+	}
+
+	v.add(path, err.Error())
+}
+
+func (v *projectValidator) add(path string, msg string) {
+
+	line := 0
+	if tokens := errorLinePattern.FindStringSubmatch(msg); tokens != nil {
+		number := tokens[1]
+		if number == "" {
+			number = tokens[2]
+		}
+		line, _ = strconv.Atoi(number)
+		msg = msg[len(tokens[0]):]
+	}
+
+	v.errors = append(v.errors, &types.ValidationError{
+		File:     v.relativePath(path),
+		Line:     line,
+		Message:  msg,
+		Severity: types.SeverityError,
+	})
+}
+
+// addAt adds error located by keys.
+func (v *projectValidator) addAt(file *yamlFile, msg string, keys ...string) {
+
+	path, line := v.locate(file, keys...)
+
+	v.errors = append(v.errors, &types.ValidationError{
+		File:     v.relativePath(path),
+		Line:     line,
+		Message:  msg,
+		Severity: types.SeverityError,
+	})
+}
+
+// locate returns path and line of keys in the overlay file or the base file.
+func (v *projectValidator) locate(file *yamlFile, keys ...string) (string, int) {
+
+	path, line := file.Path, 0
+	for _, candidate := range []string{file.OverlayPath, file.Path} {
+		if candidate == "" {
+			continue
+		}
+		content, err := ioutil.ReadFile(candidate)
+		if err != nil {
+			continue
+		}
+		if l, found := keyLine(string(content), keys...); found {
+			path, line = candidate, l
+			break
+		} else if candidate == file.Path {
+			line = l
+		}
+	}
+
+	return path, line
+}
+
+func (v *projectValidator) relativePath(path string) string {
+	if rel, err := filepath.Rel(v.projectDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// keyLine returns line number of nested keys in YAML content, and whether all keys are found.
+// If not all keys are found, it returns line of the deepest key found.
+func keyLine(content string, keys ...string) (int, bool) {
+
+	lines := strings.Split(content, "\n")
+	indent := -1
+	start := 0
+	line := 0
+
+	for _, key := range keys {
+		found := false
+		for i := start; i < len(lines); i++ {
+			trimmed := strings.TrimLeft(lines[i], " ")
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+
+			current := len(lines[i]) - len(trimmed)
+			if current <= indent {
+				break
+			}
+			if indent < 0 && current > 0 {
+				// the first key is searched only at the top level
+				continue
+			}
+
+			trimmed = strings.TrimPrefix(trimmed, "- ")
+			if strings.HasPrefix(trimmed, key+":") || strings.HasPrefix(trimmed, fmt.Sprintf("%q:", key)) {
+				found = true
+				indent = current
+				start = i + 1
+				line = i + 1
+				break
+			}
+		}
+
+		if !found {
+			return line, false
+		}
+	}
+
+	return line, true
+}
+
+// isUnresolved checks the value has external value like '${ssm:...}', which validate does not resolve.
+func isUnresolved(value string) bool {
+	return strings.Contains(value, "${")
+}
+
+func sortedContainerNames(containers map[string]types.ContainerDefinition) []string {
+
+	names := []string{}
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MergeYaml deep-merges overlay into base. Maps are merged recursively, and other values
+// (scalars, lists) are replaced by overlay. null in overlay removes the key from base.
+func MergeYaml(base string, overlay string) (string, error) {
+
+	var baseValue interface{}
+	if err := yaml.Unmarshal([]byte(base), &baseValue); err != nil {
+		return "", err
+	}
+
+	var overlayValue interface{}
+	if err := yaml.Unmarshal([]byte(overlay), &overlayValue); err != nil {
+		return "", err
+	}
+
+	merged, err := yaml.Marshal(MergeYamlValue(baseValue, overlayValue))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal merged yaml: %v", err)
+	}
+
+	return string(merged), nil
+}
+
+// MergeYamlValue deep-merges overlay into base, which are unmarshaled YAML values.
+func MergeYamlValue(base interface{}, overlay interface{}) interface{} {
+
+	baseMap, ok := base.(map[interface{}]interface{})
+	if !ok {
+		return overlay
+	}
+
+	overlayMap, ok := overlay.(map[interface{}]interface{})
+	if !ok {
+		if overlay == nil {
+			return base
+		}
+		return overlay
+	}
+
+	merged := map[interface{}]interface{}{}
+	for key, value := range baseMap {
+		merged[key] = value
+	}
+
+	for key, value := range overlayMap {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		if current, ok := merged[key]; ok {
+			merged[key] = MergeYamlValue(current, value)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
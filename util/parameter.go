@@ -0,0 +1,44 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v2"
+)
+
+// ReadParameterFile reads parameters from YAML(.yml, .yaml) or dotenv file.
+func ReadParameterFile(path string) (map[string]string, error) {
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yml" && ext != ".yaml" {
+		return godotenv.Read(path)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	params := map[string]string{}
+	for key, value := range values {
+		switch value.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return nil, fmt.Errorf("%s: parameter '%s' should be scalar value. ", path, key)
+		case nil:
+			params[key] = ""
+		default:
+			params[key] = fmt.Sprint(value)
+		}
+	}
+
+	return params, nil
+}
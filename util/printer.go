@@ -13,7 +13,7 @@ var (
 
 func Println(a ...interface{}) (int, error) {
 	if Output {
-		return fmt.Println(a...)
+		return fmt.Print(MaskSecrets(fmt.Sprintln(a...)))
 	} else {
 		return 0, nil
 	}
@@ -21,7 +21,7 @@ func Println(a ...interface{}) (int, error) {
 
 func Print(a ...interface{}) (int, error) {
 	if Output {
-		return fmt.Print(a...)
+		return fmt.Print(MaskSecrets(fmt.Sprint(a...)))
 	} else {
 		return 0, nil
 	}
@@ -29,24 +29,24 @@ func Print(a ...interface{}) (int, error) {
 
 func PrintlnCyan(format string, a ...interface{}) {
 	if Output {
-		color.Cyan(format, a...)
+		color.Cyan("%s", MaskSecrets(fmt.Sprintf(format, a...)))
 	}
 }
 
 func PrintlnGreen(format string, a ...interface{}) {
 	if Output {
-		color.Green(format, a...)
+		color.Green("%s", MaskSecrets(fmt.Sprintf(format, a...)))
 	}
 }
 
 func PrintlnYellow(format string, a ...interface{}) {
 	if Output {
-		color.Yellow(format, a...)
+		color.Yellow("%s", MaskSecrets(fmt.Sprintf(format, a...)))
 	}
 }
 
 func Infoln(a ...interface{}) {
 	if Output {
-		logger.Main.Infoln(a...)
+		logger.Main.Infoln(MaskSecrets(fmt.Sprint(a...)))
 	}
 }
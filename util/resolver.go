@@ -0,0 +1,175 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const secretMask = "******"
+
+// ValueResolver resolves external value referred as '${scheme:reference}' in YAML.
+type ValueResolver interface {
+	// Resolve returns the value of reference, and whether the value is secret.
+	// dir is the directory of YAML file which refers the value.
+	Resolve(reference string, dir string) (string, bool, error)
+}
+
+var (
+	resolvers      = map[string]ValueResolver{}
+	resolvedValues = map[string]string{}
+	secretValues   = map[string]bool{}
+	resolverMutex  sync.Mutex
+)
+
+func init() {
+	RegisterValueResolver("file", FileResolver{})
+	RegisterValueResolver("cmd", CommandResolver{})
+}
+
+// RegisterValueResolver registers resolver for '${scheme:reference}'.
+func RegisterValueResolver(scheme string, resolver ValueResolver) {
+	resolverMutex.Lock()
+	defer resolverMutex.Unlock()
+
+	resolvers[scheme] = resolver
+}
+
+// ValueResolverSchemes returns schemes of registered resolvers.
+func ValueResolverSchemes() []string {
+	resolverMutex.Lock()
+	defer resolverMutex.Unlock()
+
+	schemes := []string{}
+	for scheme := range resolvers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+
+	return schemes
+}
+
+// ResolveValue resolves reference with the resolver of scheme. Resolved values are cached during the process.
+// The lock is not held while resolving, since resolvers call AWS APIs or run commands, and MaskSecrets
+// should not wait for them. The same reference resolved in parallel may be resolved more than once.
+func ResolveValue(scheme string, reference string, dir string) (string, error) {
+
+	key := strings.Join([]string{scheme, dir, reference}, "\x00")
+
+	resolverMutex.Lock()
+	resolver, ok := resolvers[scheme]
+	value, cached := resolvedValues[key]
+	resolverMutex.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("resolver '%s' is not registered", scheme)
+	}
+	if cached {
+		return value, nil
+	}
+
+	value, secret, err := resolver.Resolve(reference, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s:%s': %v", scheme, reference, err)
+	}
+
+	resolverMutex.Lock()
+	defer resolverMutex.Unlock()
+
+	resolvedValues[key] = value
+	if secret && value != "" {
+		secretValues[value] = true
+	}
+
+	return value, nil
+}
+
+// RegisterSecret registers value to mask as secret, which is read without resolvers.
+func RegisterSecret(value string) {
+	resolverMutex.Lock()
+	defer resolverMutex.Unlock()
+
+	if value != "" {
+		secretValues[value] = true
+	}
+}
+
+// MaskSecrets replaces secret values resolved by resolvers in s.
+func MaskSecrets(s string) string {
+	resolverMutex.Lock()
+	defer resolverMutex.Unlock()
+
+	if len(secretValues) == 0 {
+		return s
+	}
+
+	// replace longer values first not to leave a part of secret
+	secrets := []string{}
+	for secret := range secretValues {
+		secrets = append(secrets, secret)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, secretMask, -1)
+	}
+
+	return s
+}
+
+func resolverPattern() *regexp.Regexp {
+	resolverMutex.Lock()
+	defer resolverMutex.Unlock()
+
+	schemes := []string{}
+	for scheme := range resolvers {
+		schemes = append(schemes, regexp.QuoteMeta(scheme))
+	}
+	sort.Strings(schemes)
+
+	return regexp.MustCompile(fmt.Sprintf(`\$\{(%s):([^}]+)\}`, strings.Join(schemes, "|")))
+}
+
+// FileResolver reads '${file:path}'. Relative path is from the directory of YAML file.
+type FileResolver struct{}
+
+func (r FileResolver) Resolve(reference string, dir string) (string, bool, error) {
+
+	path := reference
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), false, nil
+}
+
+// CommandResolver runs '${cmd:command}' by shell in the directory of YAML file, and uses its output.
+type CommandResolver struct{}
+
+func (r CommandResolver) Resolve(reference string, dir string) (string, bool, error) {
+
+	cmd := exec.Command("sh", "-c", reference)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", false, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), false, nil
+}
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+// templateSchema accepts '${KEY}', '${{ ... }}' and external values, which are replaced before parsing YAML.
+var templateSchema = map[string]interface{}{
+	"type":    "string",
+	"pattern": `\$\{`,
+}
+
+// SchemaProvider is implemented by types which are unmarshaled from several forms of YAML.
+type SchemaProvider interface {
+	JSONSchema() map[string]interface{}
+}
+
+var schemaProviderType = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+
+// JSONSchema generates JSON Schema of YAML unmarshaled into type t.
+// Properties are named by 'yaml' tags, and fields without them are skipped.
+// 'description' tag and 'enum' tag (comma separated) of fields are added to the schema.
+func JSONSchema(t reflect.Type) map[string]interface{} {
+
+	// pointers are resolved into their elements below, since nil pointers cannot call value methods.
+	if t.Kind() != reflect.Ptr && t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(SchemaProvider).JSONSchema()
+	}
+
+	switch t {
+	case reflect.TypeOf(null.Int{}):
+		return withTemplate(map[string]interface{}{"type": "integer"})
+	case reflect.TypeOf(null.Float{}):
+		return withTemplate(map[string]interface{}{"type": "number"})
+	case reflect.TypeOf(null.Bool{}):
+		return withTemplate(map[string]interface{}{"type": "boolean"})
+	case reflect.TypeOf(null.String{}):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return JSONSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return withTemplate(map[string]interface{}{"type": "integer"})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return withTemplate(map[string]interface{}{"type": "integer", "minimum": 0})
+	case reflect.Float32, reflect.Float64:
+		return withTemplate(map[string]interface{}{"type": "number"})
+	case reflect.Bool:
+		return withTemplate(map[string]interface{}{"type": "boolean"})
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": JSONSchema(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": JSONSchema(t.Elem()),
+		}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		addStructProperties(t, properties)
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func addStructProperties(t reflect.Type, properties map[string]interface{}) {
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("yaml")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+		if field.Anonymous && len(options) > 1 && options[1] == "inline" {
+			addStructProperties(field.Type, properties)
+			continue
+		}
+
+		schema := JSONSchema(field.Type)
+		if enum := field.Tag.Get("enum"); enum != "" {
+			values := []interface{}{}
+			for _, value := range strings.Split(enum, ",") {
+				values = append(values, value)
+			}
+			schema = withTemplate(map[string]interface{}{
+				"type": "string",
+				"enum": values,
+			})
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+
+		properties[options[0]] = schema
+	}
+}
+
+func withTemplate(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{schema, templateSchema},
+	}
+}
@@ -0,0 +1,159 @@
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	templateLeftDelim  = "${{"
+	templateRightDelim = "}}"
+)
+
+var (
+	variablePattern = regexp.MustCompile(`\$\{([\w-]+)\}`)
+	// defaultVariablePattern is the beginning of '${KEY|default}', whose end is found by matching braces.
+	defaultVariablePattern = regexp.MustCompile(`\$\{([\w-]+)\s*\|`)
+)
+
+// MergeYamlWithParameters renders content as template with params, and returns the result
+// and names of parameters referred in content.
+//
+// Template is text/template with '${{' and '}}' delimiters. '${KEY}' is required parameter,
+// and '${KEY|default value}' is optional parameter with default value. Braces in default value
+// should be balanced, like '${KEY|{"a":1}}'.
+// '${scheme:reference}' is resolved by ValueResolver registered for scheme.
+func MergeYamlWithParameters(name string, content []byte, params map[string]string) (string, []string, error) {
+
+	used := map[string]bool{}
+	lookup := func(key string) (string, bool) {
+		used[key] = true
+		value, ok := params[key]
+		return value, ok
+	}
+
+	funcs := template.FuncMap{
+		"param": func(key string) (string, error) {
+			value, ok := lookup(key)
+			if !ok {
+				return "", fmt.Errorf("parameter '%s' is required", key)
+			}
+			return value, nil
+		},
+		"optional": func(key string) string {
+			value, _ := lookup(key)
+			return value
+		},
+		"hasParam": func(key string) bool {
+			_, ok := lookup(key)
+			return ok
+		},
+		"resolve": func(scheme string, reference string) (string, error) {
+			return ResolveValue(scheme, reference, filepath.Dir(name))
+		},
+		"default": func(def string, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+		"upper":  strings.ToUpper,
+		"lower":  strings.ToLower,
+		"trim":   strings.TrimSpace,
+		"split":  strings.Split,
+		"join":   strings.Join,
+		"quote":  strconv.Quote,
+		"env":    os.Getenv,
+		"b64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		},
+	}
+
+	tmpl, err := template.New(name).
+		Delims(templateLeftDelim, templateRightDelim).
+		Funcs(funcs).
+		Option("missingkey=error").
+		Parse(convertVariables(string(content)))
+	if err != nil {
+		return "", []string{}, err
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, params); err != nil {
+		return "", []string{}, err
+	}
+
+	keys := []string{}
+	for key := range used {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return buffer.String(), keys, nil
+}
+
+// convertVariables converts '${scheme:reference}', '${KEY}' and '${KEY|default}' to template actions.
+func convertVariables(s string) string {
+
+	pattern := resolverPattern()
+	s = pattern.ReplaceAllStringFunc(s, func(token string) string {
+		tokens := pattern.FindStringSubmatch(token)
+		return fmt.Sprintf(`%s resolve "%s" %s %s`, templateLeftDelim, tokens[1], strconv.Quote(tokens[2]), templateRightDelim)
+	})
+
+	s = convertDefaultVariables(s)
+
+	return variablePattern.ReplaceAllString(s, fmt.Sprintf(`%s param "$1" %s`, templateLeftDelim, templateRightDelim))
+}
+
+// convertDefaultVariables converts '${KEY|default}' to template actions. Default value ends at the brace
+// which matches '${', so that it can have balanced braces. Unclosed one is left as it is.
+func convertDefaultVariables(s string) string {
+
+	var buffer bytes.Buffer
+	for {
+		loc := defaultVariablePattern.FindStringSubmatchIndex(s)
+		if loc == nil {
+			buffer.WriteString(s)
+			return buffer.String()
+		}
+
+		end := -1
+		depth := 1
+		for i := loc[1]; i < len(s) && end < 0; i++ {
+			switch s[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			buffer.WriteString(s)
+			return buffer.String()
+		}
+
+		key := s[loc[2]:loc[3]]
+		def := strings.TrimSpace(s[loc[1]:end])
+		buffer.WriteString(s[:loc[0]])
+		buffer.WriteString(fmt.Sprintf(`%s optional "%s" | default %s %s`, templateLeftDelim, key, strconv.Quote(def), templateRightDelim))
+		s = s[end+1:]
+	}
+}
@@ -12,9 +12,7 @@ import (
 )
 
 var (
-	keyValuePattern        = regexp.MustCompile(`^\s*(.+)\s*=\s*(.+)\s*$`)
-	variablePattern        = regexp.MustCompile(`\$\{([\w_-]+)\}`)
-	defaultVariablePattern = regexp.MustCompile(`\$\{([\w_-]+)\s*\|\s*([\w._-]+)\}`)
+	keyValuePattern = regexp.MustCompile(`^\s*(.+)\s*=\s*(.+)\s*$`)
 )
 
 func StringValueWithIndent(value interface{}, indent int) string {
@@ -66,32 +64,3 @@ func ParseKeyValues(slice []string) map[string]string {
 
 	return values
 }
-
-func MergeYamlWithParameters(content []byte, params map[string]string) string {
-
-	s := string(content)
-
-	defMatched := defaultVariablePattern.FindAllStringSubmatch(s, -1)
-	for _, tokens := range defMatched {
-		key := tokens[1]
-		defVal := tokens[2]
-
-		if value, ok := params[key]; ok {
-			s = strings.Replace(s, tokens[0], value, -1)
-		} else {
-			s = strings.Replace(s, tokens[0], defVal, -1)
-		}
-	}
-
-	matched := variablePattern.FindAllStringSubmatch(s, -1)
-	for _, tokens := range matched {
-		key := tokens[1]
-
-		if value, ok := params[key]; ok {
-			s = strings.Replace(s, fmt.Sprintf("${%v}", key), value, -1)
-		} else {
-			s = strings.Replace(s, tokens[0], "", -1)
-		}
-	}
-	return s
-}
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -76,14 +78,22 @@ func TestMergeYamlWithParameters(t *testing.T) {
 	params := map[string]string{
 		"NGINX_VERSION": "latest",
 		"NGINX_PORT":    "80",
+		"PARAM":         "",
 	}
 
-	actual := MergeYamlWithParameters([]byte(yaml), params)
+	actual, used, err := MergeYamlWithParameters("test.yml", []byte(yaml), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	if expect != actual {
 		t.Errorf("actula merged string is %v", actual)
 	}
 
+	if strings.Join(used, ",") != "NGINX_PORT,NGINX_VERSION,PARAM" {
+		t.Errorf("actual used parameters are %v", used)
+	}
+
 }
 
 func TestMergeYamlWithDefaultParameters(t *testing.T) {
@@ -111,10 +121,309 @@ func TestMergeYamlWithDefaultParameters(t *testing.T) {
 		"PARAM":      "hogehoge",
 	}
 
-	actual := MergeYamlWithParameters([]byte(yaml), params)
+	actual, _, err := MergeYamlWithParameters("test.yml", []byte(yaml), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expect != actual {
+		t.Errorf("actula merged string is %v", actual)
+	}
+
+}
+
+func TestMergeYamlWithBracesInDefaultParameters(t *testing.T) {
+
+	yaml := `
+	nginx:
+		environment:
+			CFG: '${CFG|{"a":{"b":1}}}'
+			PORT: ${PORT|80}
+			EMPTY: "${EMPTY|}"
+			HOSTS: ${HOSTS|a,b}
+	`
+
+	expect := `
+	nginx:
+		environment:
+			CFG: '{"a":{"b":1}}'
+			PORT: 8080
+			EMPTY: ""
+			HOSTS: a,b
+	`
+
+	actual, used, err := MergeYamlWithParameters("test.yml", []byte(yaml), map[string]string{"PORT": "8080"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expect != actual {
+		t.Errorf("actual merged string is %v", actual)
+	}
+
+	if strings.Join(used, ",") != "CFG,EMPTY,HOSTS,PORT" {
+		t.Errorf("actual used parameters are %v", used)
+	}
+
+}
+
+func TestMergeYamlWithRequiredParameters(t *testing.T) {
+
+	yaml := `
+	nginx:
+		image: openfresh/nginx:${NGINX_VERSION}
+	`
+
+	_, _, err := MergeYamlWithParameters("test.yml", []byte(yaml), map[string]string{})
+	if err == nil {
+		t.Fatal("expect error for missing required parameter")
+	}
+
+	if !strings.Contains(err.Error(), "NGINX_VERSION") || !strings.Contains(err.Error(), "test.yml") {
+		t.Errorf("actual error is %v", err)
+	}
+
+}
+
+func TestMergeYamlWithTemplate(t *testing.T) {
+
+	yaml := `
+	nginx:
+		image: openfresh/nginx:${NGINX_VERSION|http://example.com/a b}
+		environment:
+			ENV: ${{ param "ENV" | upper }}
+			TOKEN: ${{ param "TOKEN" | b64enc }}
+			HOSTS: ${{ split (param "HOSTS") "," | json }}
+${{- if hasParam "DEBUG" }}
+			DEBUG: "true"
+${{- end }}
+${{- range split (param "HOSTS") "," }}
+			HOST_${{ upper . }}: ${{ . }}
+${{- end }}
+	`
+
+	expect := `
+	nginx:
+		image: openfresh/nginx:http://example.com/a b
+		environment:
+			ENV: STAGING
+			TOKEN: c2VjcmV0
+			HOSTS: ["a","b"]
+			HOST_A: a
+			HOST_B: b
+	`
+
+	params := map[string]string{
+		"ENV":   "staging",
+		"TOKEN": "secret",
+		"HOSTS": "a,b",
+	}
+
+	actual, used, err := MergeYamlWithParameters("test.yml", []byte(yaml), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	if expect != actual {
 		t.Errorf("actula merged string is %v", actual)
 	}
 
+	if strings.Join(used, ",") != "DEBUG,ENV,HOSTS,NGINX_VERSION,TOKEN" {
+		t.Errorf("actual used parameters are %v", used)
+	}
+
+}
+
+type testResolver struct {
+	calls int
+}
+
+func (r *testResolver) Resolve(reference string, dir string) (string, bool, error) {
+	r.calls++
+	return "secret-" + reference, true, nil
+}
+
+func TestMergeYamlWithResolvers(t *testing.T) {
+
+	resolver := &testResolver{}
+	RegisterValueResolver("test", resolver)
+
+	yaml := `
+	nginx:
+		environment:
+			PASSWORD: ${test:prod/api#password}
+			PASSWORD2: ${test:prod/api#password}
+			VERSION: ${cmd:echo 1.0}
+	`
+
+	expect := `
+	nginx:
+		environment:
+			PASSWORD: secret-prod/api#password
+			PASSWORD2: secret-prod/api#password
+			VERSION: 1.0
+	`
+
+	actual, _, err := MergeYamlWithParameters("test.yml", []byte(yaml), map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expect != actual {
+		t.Errorf("actula merged string is %v", actual)
+	}
+
+	if resolver.calls != 1 {
+		t.Errorf("expect resolved value is cached, but resolver is called %d times", resolver.calls)
+	}
+
+	if masked := MaskSecrets("PASSWORD=secret-prod/api#password"); masked != "PASSWORD=******" {
+		t.Errorf("actual masked string is %v", masked)
+	}
+
+}
+
+type blockingResolver struct {
+	started chan bool
+	release chan bool
+}
+
+func (r blockingResolver) Resolve(reference string, dir string) (string, bool, error) {
+	r.started <- true
+	<-r.release
+	return "blocked-" + reference, true, nil
+}
+
+func TestResolveValueWithoutLock(t *testing.T) {
+
+	resolver := blockingResolver{
+		started: make(chan bool),
+		release: make(chan bool),
+	}
+	RegisterValueResolver("blocking", resolver)
+
+	done := make(chan string)
+	go func() {
+		value, _ := ResolveValue("blocking", "slow", ".")
+		done <- value
+	}()
+	<-resolver.started
+
+	// MaskSecrets does not wait for the resolver.
+	if masked := MaskSecrets("value"); masked != "value" {
+		t.Errorf("actual masked string is %v", masked)
+	}
+
+	close(resolver.release)
+	if value := <-done; value != "blocked-slow" {
+		t.Errorf("expect 'blocked-slow', but actual is '%s'", value)
+	}
+
+	if masked := MaskSecrets("blocked-slow"); masked != secretMask {
+		t.Errorf("expect resolved secret is masked, but actual is %v", masked)
+	}
+}
+
+func TestMergeYaml(t *testing.T) {
+
+	base := `
+nginx:
+  image: openfresh/nginx:1.0
+  memory: 256
+  ports:
+    - 80:80
+  environment:
+    APP_ENV: development
+    DEBUG: "true"
+`
+
+	overlay := `
+nginx:
+  image: openfresh/nginx:1.1
+  ports:
+    - 8080:80
+  environment:
+    APP_ENV: production
+    DEBUG: null
+`
+
+	expect := `nginx:
+  environment:
+    APP_ENV: production
+  image: openfresh/nginx:1.1
+  memory: 256
+  ports:
+  - 8080:80
+`
+
+	actual, err := MergeYaml(base, overlay)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expect != actual {
+		t.Errorf("actual merged yaml is %v", actual)
+	}
+
+}
+
+func TestJSONSchema(t *testing.T) {
+
+	type child struct {
+		Soft int64 `yaml:"soft"`
+	}
+	type entry struct {
+		Name     string
+		Mode     string            `yaml:"mode" description:"Mode." enum:"a,b"`
+		Labels   map[string]string `yaml:"labels"`
+		Children []child           `yaml:"children"`
+		Ignored  string            `yaml:"-"`
+	}
+
+	schema := JSONSchema(reflect.TypeOf(entry{}))
+
+	properties := schema["properties"].(map[string]interface{})
+	if len(properties) != 3 {
+		t.Fatalf("expect 3 properties, but actual is %v", properties)
+	}
+
+	mode := properties["mode"].(map[string]interface{})
+	if mode["description"] != "Mode." {
+		t.Errorf("expect description of mode is 'Mode.', but actual is '%v'", mode["description"])
+	}
+	enum := mode["anyOf"].([]interface{})[0].(map[string]interface{})["enum"]
+	if !reflect.DeepEqual(enum, []interface{}{"a", "b"}) {
+		t.Errorf("expect enum of mode is [a b], but actual is %v", enum)
+	}
+
+	labels := properties["labels"].(map[string]interface{})
+	if labels["type"] != "object" || labels["additionalProperties"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("expect labels is map of string, but actual is %v", labels)
+	}
+
+	items := properties["children"].(map[string]interface{})["items"].(map[string]interface{})
+	soft := items["properties"].(map[string]interface{})["soft"].(map[string]interface{})
+	if soft["anyOf"].([]interface{})[0].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("expect soft is integer, but actual is %v", soft)
+	}
+}
+
+type schemaSize int64
+
+func (s schemaSize) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+func TestJSONSchemaWithProviderPointer(t *testing.T) {
+
+	type entry struct {
+		Size *schemaSize `yaml:"size"`
+	}
+
+	schema := JSONSchema(reflect.TypeOf(entry{}))
+
+	size := schema["properties"].(map[string]interface{})["size"].(map[string]interface{})
+	if size["type"] != "string" {
+		t.Errorf("expect size is schema of provider, but actual is %v", size)
+	}
 }